@@ -1,7 +1,9 @@
 package makross
 
 import (
+	"context"
 	"net/http"
+	"net/url"
 )
 
 // WrapHTTPHandler wraps `http.Handler` into `makross.Handler`.
@@ -11,3 +13,41 @@ func WrapHTTPHandler(handler http.Handler) Handler {
 		return nil
 	}
 }
+
+// mountParamsKey is the key of the request context holding the parameters of the mount point, given as
+// name and value pairs, which fill the URLs created by a mounted makross.
+type mountParamsKey struct{}
+
+// mountHandler returns a handler that passes the request to the mounted handler with the mount prefix stripped.
+// If wildcard is true, the remaining path is taken from the last route parameter; otherwise it is "/".
+func mountHandler(handler http.Handler, wildcard bool) Handler {
+	return func(c *Context) error {
+		path := "/"
+		n := len(c.pnames)
+		if wildcard && n > 0 {
+			n--
+			path += c.pvalues[n]
+		}
+		params := mountParams(c.Request)
+		if n > 0 {
+			pairs := make([]interface{}, 0, 2*n+len(params))
+			for i, name := range c.pnames[:n] {
+				pairs = append(pairs, name, c.pvalues[i])
+			}
+			params = append(pairs, params...)
+		}
+		req := c.Request.WithContext(context.WithValue(c.Request.Context(), mountParamsKey{}, params))
+		req.URL = new(url.URL)
+		*req.URL = *c.Request.URL
+		req.URL.Path = path
+		req.URL.RawPath = ""
+		handler.ServeHTTP(c.Response, req)
+		return c.Abort()
+	}
+}
+
+// mountParams returns the parameters of the mount points of the request.
+func mountParams(req *http.Request) []interface{} {
+	params, _ := req.Context().Value(mountParamsKey{}).([]interface{})
+	return params
+}
@@ -9,14 +9,21 @@ import (
 type (
 	Args struct {
 		s string
+		v interface{} // the converted value of a typed route parameter
 	}
 )
 
 func (a *Args) MustInt() int {
+	if v, ok := a.v.(int); ok {
+		return v
+	}
 	return com.StrTo(a.s).MustInt()
 }
 
 func (a *Args) MustInt64() int64 {
+	if v, ok := a.v.(int); ok {
+		return int64(v)
+	}
 	return com.StrTo(a.s).MustInt64()
 }
 
@@ -25,6 +32,9 @@ func (a *Args) MustUint8() uint8 {
 }
 
 func (a *Args) MustUint() uint {
+	if v, ok := a.v.(uint); ok {
+		return v
+	}
 	return uint(com.StrTo(a.s).MustInt64())
 }
 
@@ -42,20 +52,29 @@ func (a *Args) MustFloat32() (f float32) {
 }
 
 func (a *Args) Float64() (f float64, e error) {
+	if v, ok := a.v.(float64); ok {
+		return v, nil
+	}
 	f, e = strconv.ParseFloat(a.s, 64)
 	return
 }
 
 func (a *Args) MustFloat64() (f float64) {
-	f, _ = strconv.ParseFloat(a.s, 64)
+	f, _ = a.Float64()
 	return
 }
 
 func (a *Args) Int() (int, error) {
+	if v, ok := a.v.(int); ok {
+		return v, nil
+	}
 	return com.StrTo(a.s).Int()
 }
 
 func (a *Args) Int64() (int64, error) {
+	if v, ok := a.v.(int); ok {
+		return int64(v), nil
+	}
 	return com.StrTo(a.s).Int64()
 }
 
@@ -68,10 +87,22 @@ func (a *Args) Bytes() []byte {
 }
 
 func (a *Args) Time() time.Time {
+	if v, ok := a.v.(time.Time); ok {
+		return v
+	}
 	tme, _ := time.Parse("2006-01-02 03:04:05 PM", com.StrTo(a.s).String())
 	return tme
 }
 
+// Value returns the converted value of a typed route parameter, such as an int for "<id:int>".
+// For other arguments, the value is returned as a string.
+func (a *Args) Value() interface{} {
+	if a.v != nil {
+		return a.v
+	}
+	return a.s
+}
+
 func (a *Args) Exist() bool {
 	return com.StrTo(a.s).Exist()
 }
@@ -99,11 +130,20 @@ func (c *Context) Param(name string) *Args {
 	for i, n := range c.pnames {
 		if n == name {
 			a.s = c.pvalues[i]
+			a.v = c.paramValue(i)
 		}
 	}
 	return a
 }
 
+// paramValue returns the converted value of the i-th route parameter, or nil if the parameter is not typed.
+func (c *Context) paramValue(i int) interface{} {
+	if c.route != nil && i < len(c.ptyped) {
+		return c.ptyped[i]
+	}
+	return nil
+}
+
 func (c *Context) FormArgs(key ...string) *Args {
 	var a = new(Args)
 	var k string
@@ -123,6 +163,7 @@ func (c *Context) Args(key ...string) *Args {
 		for i, n := range c.pnames {
 			if n == k {
 				a.s = c.pvalues[i]
+				a.v = c.paramValue(i)
 			}
 		}
 		if len(a.s) == 0 {
@@ -5,10 +5,14 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/textproto"
 	"reflect"
 	"strconv"
 	"strings"
+
+	"github.com/insionng/makross/libraries/msgpack"
 )
 
 type (
@@ -28,17 +32,76 @@ type (
 )
 
 // Bind implements the `Binder#Bind` function.
+//
+// The data is populated from the request body according to its content type, and then the fields of
+// a struct are populated from the other sources of the request by their struct tags:
+//
+//	query:"name"   the URL query parameters
+//	header:"name"  the request headers
+//	cookie:"name"  the cookies
+//	param:"name"   the route parameters
+//
+// The sources are bound in the order listed above, after the body, so when a field is tagged for
+// several sources, the route parameters take precedence over the cookies, which take precedence over
+// the headers and then the body. The body takes precedence over the query parameters: for a request
+// with a body, the fields also tagged for the body (json, xml, form, msgpack or protobuf) are bound from
+// the body only, even if it does not set them. The fields implementing BindUnmarshaler are decoded by
+// UnmarshalParam for all of these sources.
+// For GET and DELETE requests without a body, the untagged fields are bound to the query parameters
+// of the same names. A request declaring a content type without a body is rejected, except for GET,
+// HEAD and DELETE requests.
 func (b *DefaultBinder) Bind(i interface{}, c *Context) (err error) {
 	req := c.Request
 	if req.ContentLength == 0 {
-		if req.Method == GET || req.Method == DELETE {
-			if err = b.bindData(i, c.QueryParams(), "query"); err != nil {
-				return NewHTTPError(http.StatusBadRequest, err.Error())
-			}
-			return
+		if req.Method != GET && req.Method != DELETE && req.Method != HEAD && req.Header.Get(HeaderContentType) != "" {
+			return NewHTTPError(http.StatusBadRequest, "Request body can't be empty")
+		}
+	} else if err = b.bindBody(i, c); err != nil {
+		return
+	}
+	if err = b.bindSources(i, c); err != nil {
+		return NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return
+}
+
+// bindSources binds the query parameters, headers, cookies and route parameters to the tagged fields of a struct.
+func (b *DefaultBinder) bindSources(i interface{}, c *Context) error {
+	if typ := reflect.TypeOf(i); typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	req := c.Request
+
+	hasBody := req.ContentLength != 0
+	byName := !hasBody && (req.Method == GET || req.Method == DELETE)
+	if err := b.bindFields(i, c.QueryParams(), "query", byName, hasBody); err != nil {
+		return err
+	}
+
+	if err := b.bindFields(i, req.Header, "header", false, false); err != nil {
+		return err
+	}
+
+	cookies := map[string][]string{}
+	for _, cookie := range req.Cookies() {
+		cookies[cookie.Name] = append(cookies[cookie.Name], cookie.Value)
+	}
+	if err := b.bindFields(i, cookies, "cookie", false, false); err != nil {
+		return err
+	}
+
+	params := map[string][]string{}
+	for j, name := range c.pnames {
+		if j < len(c.pvalues) {
+			params[name] = append(params[name], c.pvalues[j])
 		}
-		return NewHTTPError(http.StatusBadRequest, "Request body can't be empty")
 	}
+	return b.bindFields(i, params, "param", false, false)
+}
+
+// bindBody binds the request body according to its content type.
+func (b *DefaultBinder) bindBody(i interface{}, c *Context) (err error) {
+	req := c.Request
 	ctype := req.Header.Get(HeaderContentType)
 	switch {
 	case strings.HasPrefix(ctype, MIMEApplicationJSON):
@@ -61,6 +124,25 @@ func (b *DefaultBinder) Bind(i interface{}, c *Context) (err error) {
 				return NewHTTPError(http.StatusBadRequest, err.Error())
 			}
 		}
+	case strings.HasPrefix(ctype, MIMEApplicationMsgpack), strings.HasPrefix(ctype, MIME_MSGPACK2):
+		body, err := ioutil.ReadAll(req.Body)
+		if err == nil {
+			err = msgpack.Unmarshal(body, i)
+		}
+		if err != nil {
+			return NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	case strings.HasPrefix(ctype, MIMEApplicationProtobuf), strings.HasPrefix(ctype, MIME_PROTOBUF2):
+		body, err := ioutil.ReadAll(req.Body)
+		if err == nil {
+			err = ProtobufUnmarshal(body, i)
+		}
+		if err == ErrNotProtoMessage {
+			return ErrUnsupportedMediaType
+		}
+		if err != nil {
+			return NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 	case strings.HasPrefix(ctype, MIMEApplicationForm), strings.HasPrefix(ctype, MIMEMultipartForm):
 		params, err := c.FormParams()
 		if err != nil {
@@ -75,7 +157,24 @@ func (b *DefaultBinder) Bind(i interface{}, c *Context) (err error) {
 	return
 }
 
+// isBodyField returns whether the field is tagged for one of the formats of the request body.
+func isBodyField(field reflect.StructField) bool {
+	for _, tag := range []string{"json", "xml", "form", "msgpack", "protobuf"} {
+		if name := field.Tag.Get(tag); name != "" && name != "-" {
+			return true
+		}
+	}
+	return false
+}
+
 func (b *DefaultBinder) bindData(ptr interface{}, data map[string][]string, tag string) error {
+	return b.bindFields(ptr, data, tag, true, false)
+}
+
+// bindFields binds the data to the fields of the struct by the names in the given tag. If byName is true,
+// the fields without the tag are bound by their names, otherwise only the nested structs are looked into.
+// If skipBodyFields is true, the fields tagged for the request body are left to it.
+func (b *DefaultBinder) bindFields(ptr interface{}, data map[string][]string, tag string, byName, skipBodyFields bool) error {
 	typ := reflect.TypeOf(ptr).Elem()
 	val := reflect.ValueOf(ptr).Elem()
 
@@ -96,12 +195,20 @@ func (b *DefaultBinder) bindData(ptr interface{}, data map[string][]string, tag
 			inputFieldName = typeField.Name
 			// If tag is nil, we inspect if the field is a struct.
 			if _, ok := bindUnmarshaler(structField); !ok && structFieldKind == reflect.Struct {
-				err := b.bindData(structField.Addr().Interface(), data, tag)
+				err := b.bindFields(structField.Addr().Interface(), data, tag, byName, skipBodyFields)
 				if err != nil {
 					return err
 				}
 				continue
 			}
+			if !byName {
+				continue
+			}
+		} else if tag == "header" {
+			inputFieldName = textproto.CanonicalMIMEHeaderKey(inputFieldName)
+		}
+		if skipBodyFields && isBodyField(typeField) {
+			continue
 		}
 		inputValue, exists := data[inputFieldName]
 		if !exists {
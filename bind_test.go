@@ -332,3 +332,89 @@ func testBindError(t *testing.T, r io.Reader, ctype string) {
 		}
 	}
 }
+
+func TestBindSources(t *testing.T) {
+	type request struct {
+		ID       int       `param:"id" json:"id"`
+		Tenant   string    `header:"x-tenant"`
+		Session  string    `cookie:"sid"`
+		Page     int       `query:"page"`
+		Name     string    `json:"name" query:"name"`
+		Since    Timestamp `header:"X-Since"`
+		Tags     []string  `query:"tag"`
+		Untagged string
+	}
+
+	m := New()
+	var result request
+	var bindErr error
+	handler := func(c *Context) error {
+		result = request{}
+		bindErr = c.Bind(&result)
+		return nil
+	}
+	m.Put("/users/<id>", handler)
+	m.Get("/users/<id>", handler)
+
+	req := httptest.NewRequest(PUT, "/users/7?page=2&name=query&tag=a&tag=b&Untagged=x", strings.NewReader(`{"id":1,"name":"body"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("X-Since", "2016-12-06T19:09:05Z")
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "abc"})
+	m.ServeHTTP(httptest.NewRecorder(), req)
+	if assert.NoError(t, bindErr) {
+		// the route parameter takes precedence over the body, and the body over the query parameter
+		assert.Equal(t, 7, result.ID)
+		assert.Equal(t, "body", result.Name)
+		assert.Equal(t, "acme", result.Tenant)
+		assert.Equal(t, "abc", result.Session)
+		assert.Equal(t, 2, result.Page)
+		assert.Equal(t, []string{"a", "b"}, result.Tags)
+		assert.Equal(t, Timestamp(time.Date(2016, 12, 6, 19, 9, 5, 0, time.UTC)), result.Since)
+		assert.Equal(t, "", result.Untagged)
+	}
+
+	// the fields tagged for the body are not bound to the query parameters of a request with a body
+	req = httptest.NewRequest(PUT, "/users/7?name=query&page=3", strings.NewReader(`{}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+	if assert.NoError(t, bindErr) {
+		assert.Equal(t, "", result.Name)
+		assert.Equal(t, 3, result.Page)
+	}
+	req = httptest.NewRequest(PUT, "/users/7?name=query", nil)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+	if assert.NoError(t, bindErr) {
+		assert.Equal(t, "query", result.Name)
+	}
+
+	// the untagged fields are bound to the query parameters of GET requests
+	req = httptest.NewRequest(GET, "/users/7?Untagged=x", nil)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+	if assert.NoError(t, bindErr) {
+		assert.Equal(t, 7, result.ID)
+		assert.Equal(t, "x", result.Untagged)
+	}
+
+	// a request without body may be bound from the other sources
+	req = httptest.NewRequest(PUT, "/users/8", nil)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+	if assert.NoError(t, bindErr) {
+		assert.Equal(t, 8, result.ID)
+	}
+
+	req = httptest.NewRequest(PUT, "/users/abc", strings.NewReader(`{}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+	if assert.IsType(t, new(HTTPError), bindErr) {
+		assert.Equal(t, http.StatusBadRequest, bindErr.(*HTTPError).StatusCode())
+	}
+
+	// the data other than structs is only bound from the body
+	e := New()
+	req = httptest.NewRequest(POST, "/?a=1", strings.NewReader(`{"a":2}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	data := map[string]int{}
+	assert.NoError(t, e.NewContext(req, httptest.NewRecorder()).Bind(&data))
+	assert.Equal(t, map[string]int{"a": 2}, data)
+}
@@ -0,0 +1,88 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SetETag sets the ETag header of the response. The tag is quoted if it is not already,
+// and marked as weak if weak is true.
+// Call NotModified after setting the validators to skip computing the body when the client's copy is fresh.
+func (c *Context) SetETag(etag string, weak ...bool) {
+	if !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+		etag = `"` + etag + `"`
+	}
+	if len(weak) > 0 && weak[0] && !strings.HasPrefix(etag, "W/") {
+		etag = "W/" + etag
+	}
+	c.Response.Header().Set(HeaderETag, etag)
+}
+
+// SetLastModified sets the Last-Modified header of the response.
+func (c *Context) SetLastModified(t time.Time) {
+	if !t.IsZero() {
+		c.Response.Header().Set(HeaderLastModified, t.UTC().Format(http.TimeFormat))
+	}
+}
+
+// NotModified evaluates the If-None-Match and If-Modified-Since headers of the request against the
+// ETag and Last-Modified headers of the response. If the client's copy is fresh, it writes a 304 (Not Modified)
+// response for GET and HEAD requests, or a 412 (Precondition Failed) response for the other methods
+// whose If-None-Match header matches, aborts the handler chain and returns true.
+//
+//	c.SetETag(article.Version)
+//	if c.NotModified() {
+//		return nil
+//	}
+//	return c.JSON(render(article))
+func (c *Context) NotModified() bool {
+	req, header := c.Request, c.Response.Header()
+	safe := req.Method == GET || req.Method == HEAD
+
+	if inm := req.Header.Get(HeaderIfNoneMatch); inm != "" {
+		if !etagMatch(inm, header.Get(HeaderETag)) {
+			return false
+		}
+	} else if ims := req.Header.Get(HeaderIfModifiedSince); ims != "" && safe {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		lm, err := http.ParseTime(header.Get(HeaderLastModified))
+		if err != nil || lm.After(t) {
+			return false
+		}
+	} else {
+		return false
+	}
+
+	if safe {
+		// a 304 response does not carry the representation headers
+		header.Del(HeaderContentType)
+		header.Del(HeaderContentLength)
+		header.Del(HeaderContentEncoding)
+		c.Response.WriteHeader(StatusNotModified)
+	} else {
+		c.Response.WriteHeader(StatusPreconditionFailed)
+	}
+	c.Abort()
+	return true
+}
+
+// etagMatch reports whether the If-None-Match list matches the ETag with the weak comparison.
+func etagMatch(list, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextSetETag(t *testing.T) {
+	m := New()
+	c := m.NewContext(httptest.NewRequest(GET, "/", nil), httptest.NewRecorder())
+	c.SetETag("v1")
+	assert.Equal(t, `"v1"`, c.Response.Header().Get(HeaderETag))
+	c.SetETag("v1", true)
+	assert.Equal(t, `W/"v1"`, c.Response.Header().Get(HeaderETag))
+	c.SetETag(`W/"v2"`, true)
+	assert.Equal(t, `W/"v2"`, c.Response.Header().Get(HeaderETag))
+
+	c.SetLastModified(time.Date(2017, 1, 2, 3, 4, 5, 0, time.FixedZone("CST", 8*3600)))
+	assert.Equal(t, "Sun, 01 Jan 2017 19:04:05 GMT", c.Response.Header().Get(HeaderLastModified))
+}
+
+func TestContextNotModified(t *testing.T) {
+	modified := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := New()
+	handler := func(c *Context) error {
+		c.SetETag("v1")
+		c.SetLastModified(modified)
+		if c.NotModified() {
+			return nil
+		}
+		c.Response.Header().Set(HeaderContentType, MIMETextPlainCharsetUTF8)
+		return c.String("body")
+	}
+	m.Get("/", handler)
+	m.Put("/", handler)
+	m.Head("/", handler)
+
+	tests := []struct {
+		method, header, value string
+		status                int
+	}{
+		{GET, "", "", http.StatusOK},
+		{GET, HeaderIfNoneMatch, `"v1"`, http.StatusNotModified},
+		{GET, HeaderIfNoneMatch, `W/"v1"`, http.StatusNotModified},
+		{GET, HeaderIfNoneMatch, `"v0", "v1"`, http.StatusNotModified},
+		{GET, HeaderIfNoneMatch, "*", http.StatusNotModified},
+		{GET, HeaderIfNoneMatch, `"v2"`, http.StatusOK},
+		{HEAD, HeaderIfNoneMatch, `"v1"`, http.StatusNotModified},
+		{PUT, HeaderIfNoneMatch, `"v1"`, http.StatusPreconditionFailed},
+		{PUT, HeaderIfNoneMatch, `"v2"`, http.StatusOK},
+		{GET, HeaderIfModifiedSince, modified.Format(http.TimeFormat), http.StatusNotModified},
+		{GET, HeaderIfModifiedSince, modified.Add(time.Hour).Format(http.TimeFormat), http.StatusNotModified},
+		{GET, HeaderIfModifiedSince, modified.Add(-time.Second).Format(http.TimeFormat), http.StatusOK},
+		{GET, HeaderIfModifiedSince, "invalid", http.StatusOK},
+		{PUT, HeaderIfModifiedSince, modified.Format(http.TimeFormat), http.StatusOK},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/", nil)
+		if test.header != "" {
+			req.Header.Set(test.header, test.value)
+		}
+		res := httptest.NewRecorder()
+		m.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, "%v %v: %v", test.method, test.header, test.value)
+		if test.status == http.StatusNotModified {
+			assert.Equal(t, "", res.Body.String())
+			assert.Equal(t, "", res.Header().Get(HeaderContentType))
+			assert.Equal(t, `"v1"`, res.Header().Get(HeaderETag))
+		}
+	}
+}
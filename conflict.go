@@ -0,0 +1,244 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// ConflictKind describes how a route conflicts with a route added before it.
+type ConflictKind string
+
+// Route conflict kinds
+const (
+	// ConflictDuplicate means both routes match exactly the same requests.
+	ConflictDuplicate ConflictKind = "duplicate"
+	// ConflictShadowed means every request matching the route is taken by the previous route.
+	ConflictShadowed ConflictKind = "shadowed"
+	// ConflictAmbiguous means some requests match both routes and are taken by the previous route.
+	ConflictAmbiguous ConflictKind = "ambiguous"
+)
+
+// RouteConflict describes a route whose requests are partially or completely taken by a route added before it.
+// Because the route added first takes precedence, the conflicting route is partially or completely unreachable.
+type RouteConflict struct {
+	Kind     ConflictKind
+	Route    *Route // the route added later
+	Previous *Route // the route taking precedence
+}
+
+// Error returns the description of the conflict.
+func (c *RouteConflict) Error() string {
+	switch c.Kind {
+	case ConflictDuplicate:
+		return fmt.Sprintf("route %v duplicates %v", c.Route, c.Previous)
+	case ConflictShadowed:
+		return fmt.Sprintf("route %v is shadowed by %v", c.Route, c.Previous)
+	}
+	return fmt.Sprintf("route %v is ambiguous with %v", c.Route, c.Previous)
+}
+
+// Validate checks all routes managed by the makross and returns the conflicts found among them.
+// Only the conflicts that can be determined from the route patterns are reported; two different
+// regular expressions in the same position are assumed not to overlap.
+func (m *Makross) Validate() []*RouteConflict {
+	var conflicts []*RouteConflict
+	for i, route := range m.routes {
+		for _, previous := range m.routes[:i] {
+			if c := findRouteConflict(previous, route); c != nil {
+				conflicts = append(conflicts, c)
+			}
+		}
+	}
+	return conflicts
+}
+
+// checkRouteConflicts logs the conflicts between the given route and the routes added before it.
+func (t *routeTable) checkRouteConflicts(route *Route) {
+	for _, previous := range t.routes {
+		if previous == route {
+			break
+		}
+		if c := findRouteConflict(previous, route); c != nil {
+			log.Println("[Makross] " + c.Error())
+		}
+	}
+}
+
+// findRouteConflict returns the conflict between two routes, where previous is added before route.
+// Nil is returned if they do not conflict.
+func findRouteConflict(previous, route *Route) *RouteConflict {
+	if previous.method != route.method || previous.group.host != route.group.host {
+		return nil
+	}
+	covers, covered, ok := compareSegments(previous.segments(), route.segments())
+	switch {
+	case !ok:
+		return nil
+	case covers && covered:
+		return &RouteConflict{ConflictDuplicate, route, previous}
+	case covers:
+		return &RouteConflict{ConflictShadowed, route, previous}
+	case covered:
+		// the previous route is more specific: the route still matches other requests
+		return nil
+	}
+	return &RouteConflict{ConflictAmbiguous, route, previous}
+}
+
+// routeSegment is a part of a route pattern between two slashes.
+type routeSegment struct {
+	key      string         // the segment with the names removed from the parameter tokens
+	static   bool           // whether the segment contains no parameter token
+	token    bool           // whether the segment is a single parameter token
+	any      bool           // whether the segment is a single token matching any non-slash characters
+	wildcard bool           // whether the segment is a single token matching the rest of the path
+	regex    *regexp.Regexp // the regular expression matching the whole segment
+	ptypes   []*ParamType   // the types of the parameter tokens in the segment
+}
+
+// segments returns the segments of the route pattern.
+func (r *Route) segments() []routeSegment {
+	if r.segs == nil {
+		path := r.group.prefix + r.path
+		if strings.HasSuffix(path, "*") {
+			path = path[:len(path)-1] + "<:.*>"
+		}
+		r.segs = parseSegments(path)
+	}
+	return r.segs
+}
+
+// parseSegments splits a route pattern into segments separated by the slashes outside of parameter tokens.
+func parseSegments(path string) []routeSegment {
+	var segs []routeSegment
+	seg := routeSegment{static: true}
+	expr, tokens := "", 0
+	start := -1
+	flush := func() {
+		seg.token = tokens == 1 && seg.key[0] == '<' && seg.key[len(seg.key)-1] == '>'
+		seg.any = seg.token && seg.key == "<>"
+		seg.wildcard = seg.token && seg.key == "<.*>"
+		seg.regex = regexp.MustCompile("^" + expr + "$")
+		segs = append(segs, seg)
+		seg = routeSegment{static: true}
+		expr, tokens = "", 0
+	}
+	for i := 0; i < len(path); i++ {
+		switch {
+		case start >= 0:
+			if path[i] == '>' {
+				pattern := ""
+				if j := strings.IndexByte(path[start:i], ':'); j >= 0 {
+					pattern = path[start+j+1 : i]
+				}
+				seg.key += "<" + pattern + ">"
+				t := paramTypes[pattern]
+				switch {
+				case t != nil:
+					expr += "(?:" + t.Pattern + ")"
+				case pattern == "":
+					expr += "[^/]*"
+				default:
+					expr += "(?:" + pattern + ")"
+				}
+				seg.ptypes = append(seg.ptypes, t)
+				seg.static = false
+				tokens++
+				start = -1
+			}
+		case path[i] == '<':
+			start = i
+		case path[i] == '/':
+			flush()
+		default:
+			seg.key += path[i : i+1]
+			expr += regexp.QuoteMeta(path[i : i+1])
+		}
+	}
+	if start >= 0 {
+		// an unclosed token is treated as static text
+		seg.key += path[start:]
+		expr += regexp.QuoteMeta(path[start:])
+	}
+	flush()
+	return segs
+}
+
+// compareSegments compares the requests matched by two lists of segments.
+// covers reports whether a matches every request matched by b, and covered whether b matches every request
+// matched by a. ok is false if no request can be determined to match both.
+func compareSegments(a, b []routeSegment) (covers, covered, ok bool) {
+	covers, covered = true, true
+	for i := 0; i < len(a) || i < len(b); i++ {
+		if i < len(a) && a[i].wildcard && i < len(b) {
+			if !b[i].wildcard || i < len(b)-1 {
+				covered = false
+			}
+			return covers, covered, true
+		}
+		if i < len(b) && b[i].wildcard && i < len(a) {
+			return false, covered, true
+		}
+		if i >= len(a) || i >= len(b) {
+			return false, false, false
+		}
+		switch compareSegment(a[i], b[i]) {
+		case segmentCovers:
+			covered = false
+		case segmentCovered:
+			covers = false
+		case segmentUnknown:
+			return false, false, false
+		}
+	}
+	return covers, covered, true
+}
+
+const (
+	segmentEqual = iota
+	segmentCovers
+	segmentCovered
+	segmentUnknown
+)
+
+// compareSegment compares the values matched by two segments.
+// segmentUnknown is returned if they are disjoint or the relationship cannot be determined.
+func compareSegment(a, b routeSegment) int {
+	switch {
+	case a.key == b.key:
+		return segmentEqual
+	case a.static && b.static:
+		return segmentUnknown
+	case b.static:
+		if a.matches(b.key) {
+			return segmentCovers
+		}
+		return segmentUnknown
+	case a.static:
+		if b.matches(a.key) {
+			return segmentCovered
+		}
+		return segmentUnknown
+	case a.any:
+		return segmentCovers
+	case b.any:
+		return segmentCovered
+	}
+	return segmentUnknown
+}
+
+// matches reports whether the segment matches the given static value.
+func (s routeSegment) matches(value string) bool {
+	if !s.regex.MatchString(value) {
+		return false
+	}
+	if s.token && s.ptypes[0] != nil && s.ptypes[0].Convert != nil {
+		_, err := s.ptypes[0].Convert(value)
+		return err == nil
+	}
+	return true
+}
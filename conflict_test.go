@@ -0,0 +1,75 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindRouteConflict(t *testing.T) {
+	tests := []struct {
+		previous, route string
+		kind            ConflictKind
+	}{
+		{"/users", "/users", ConflictDuplicate},
+		{"/users/<id>", "/users/<name>", ConflictDuplicate},
+		{`/users/<id:\d+>`, `/users/<name:\d+>`, ConflictDuplicate},
+		{"/users/<id>", `/users/<name:\w+>`, ConflictShadowed},
+		{"/users/<id>", "/users/me", ConflictShadowed},
+		{"/users/<id:int>", "/users/123", ConflictShadowed},
+		{"/users/*", "/users/<id>/profile", ConflictShadowed},
+		{"/users/*", "/users/", ConflictShadowed},
+		{"/users/*", "/users/*", ConflictDuplicate},
+		{"/users/<id>/posts", "/users/me/<action>", ConflictAmbiguous},
+		{"/users/me", "/users/<id>", ""},
+		{"/users/<id>", "/users/*", ""},
+		{"/users/<id>", "/posts/<id>", ""},
+		{"/users/<id>", "/users/<id>/posts", ""},
+		{"/users/<id:int>", "/users/abc", ""},
+		{"/users/<id:int>", "/users/<u:uuid>", ""},
+		{`/users/<id:\d+>`, `/users/<name:[a-z]+>`, ""},
+		{"/users/*", "/users", ""},
+		{"/users/accnt-<id>", "/users/accnt-1", ConflictShadowed},
+		{"/users/accnt-<id>", "/users/<id>", ""},
+	}
+	for _, test := range tests {
+		m := New()
+		r1 := m.Get(test.previous)
+		r2 := m.Get(test.route)
+		c := findRouteConflict(r1, r2)
+		if test.kind == "" {
+			assert.Nil(t, c, test.previous+" vs "+test.route)
+		} else if assert.NotNil(t, c, test.previous+" vs "+test.route) {
+			assert.Equal(t, test.kind, c.Kind, test.previous+" vs "+test.route)
+			assert.Equal(t, r2, c.Route)
+			assert.Equal(t, r1, c.Previous)
+		}
+	}
+}
+
+func TestMakrossValidate(t *testing.T) {
+	m := New()
+	m.Get("/users/<id>")
+	m.Post("/users/<id>")
+	m.Get("/users/me")
+	m.Get("/users/<name>")
+	m.Host("api.example.com").Get("/users/<id>")
+	m.Group("/admin").Get("/users/<id>")
+	assert.Nil(t, New().Validate())
+
+	conflicts := m.Validate()
+	if assert.Equal(t, 2, len(conflicts)) {
+		assert.Equal(t, "route GET /users/me is shadowed by GET /users/<id>", conflicts[0].Error())
+		assert.Equal(t, "route GET /users/<name> duplicates GET /users/<id>", conflicts[1].Error())
+	}
+
+	m = New()
+	m.Get("/users/<id>/posts")
+	m.Get("/users/me/<action>")
+	conflicts = m.Validate()
+	if assert.Equal(t, 1, len(conflicts)) {
+		assert.Equal(t, "route GET /users/me/<action> is ambiguous with GET /users/<id>/posts", conflicts[0].Error())
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/insionng/makross/libraries/i18n"
+	"github.com/insionng/makross/libraries/msgpack"
 )
 
 const (
@@ -36,16 +37,24 @@ type (
 		Response *Response     // the response writer
 		ktx      ktx.Context   // standard context
 		Localer
-		Flash      *Flash
-		Session    Sessioner
-		makross    *Makross
-		pnames     []string               // list of route parameter names
-		pvalues    []string               // list of parameter values corresponding to pnames
-		data       map[string]interface{} // data items managed by Get and Set
-		FiltersMap *sync.Map              //map[string][]byte      // Not Global Filters, only in Context
-		index      int                    // the index of the currently executing handler in handlers
-		handlers   []Handler              // the handlers associated with the current route
-		writer     DataWriter
+		Flash                *Flash
+		Session              Sessioner
+		makross              *Makross
+		route                *Route                 // the route matching the current request
+		pnames               []string               // list of route parameter names
+		pvalues              []string               // list of parameter values corresponding to pnames
+		ptyped               []interface{}          // the values of pvalues converted while matching the route, nil for the untyped parameters
+		data                 map[string]interface{} // data items managed by Get and Set
+		hooks                *hookSet               // the hooks added for the current request only
+		beforeResponseHooked bool                   // whether the response runs the HookBeforeResponse hooks
+		streamed             bool                   // whether the response has been started by JSONStream
+		index                int                    // the index of the currently executing handler in handlers
+		handlers             []Handler              // the handlers associated with the current route
+		writer               DataWriter
+
+		// Deprecated: FiltersMap holds the last result of DoFilterHook for each key of the current request;
+		// use the value it returns.
+		FiltersMap *sync.Map //map[string][]byte // Not Global Filters, only in Context
 	}
 
 	// Localer reprents a localization interface.
@@ -63,9 +72,18 @@ type (
 func (c *Context) Reset(w http.ResponseWriter, r *http.Request) {
 	c.Response.reset(w)
 	c.Request = r
+	c.route = nil
 	c.ktx = ktx.Background()
+	if r != nil {
+		c.ktx = r.Context()
+	}
 	c.data = nil
+	c.Flash = nil
+	c.Session = nil
+	c.hooks = nil
 	c.FiltersMap = new(sync.Map)
+	c.beforeResponseHooked = false
+	c.streamed = false
 	c.index = -1
 	c.writer = DefaultDataWriter
 }
@@ -104,12 +122,25 @@ func (c *Context) Close() error {
 	return c.makross.Server.Close()
 }
 
+// Route returns the route matching the current request.
+// Nil is returned if no route matches the request.
+func (c *Context) Route() *Route {
+	return c.route
+}
+
+// Kontext returns the standard context of the request. It is canceled when the client
+// disconnects, when the request is done, or when the deadline set by Timeout expires.
 func (c *Context) Kontext() ktx.Context {
 	return c.ktx
 }
 
+// SetKontext replaces the standard context of the request. The context is also attached to
+// the request, so that the handlers calling Request.Context() will get it as well.
 func (c *Context) SetKontext(ktx ktx.Context) {
 	c.ktx = ktx
+	if c.Request != nil {
+		c.Request = c.Request.WithContext(ktx)
+	}
 }
 
 func (c *Context) Handler() Handler {
@@ -208,6 +239,20 @@ func (c *Context) GetStore() map[string]interface{} {
 	return c.data
 }
 
+// TemplateData returns the data items of the context passed to the templates by the renderers,
+// with the flash messages of the request available as "Flash" even if they have not been set in the context.
+func (c *Context) TemplateData() map[string]interface{} {
+	if c.Flash == nil {
+		return c.data
+	}
+	data := make(map[string]interface{}, len(c.data)+1)
+	for k, v := range c.data {
+		data[k] = v
+	}
+	data["Flash"] = c.Flash
+	return data
+}
+
 func (c *Context) Pull(key string) interface{} {
 	return c.makross.data[key]
 }
@@ -232,8 +277,23 @@ func (c *Context) PushStore(data map[string]interface{}) {
 	}
 }
 
+// Bind populates the data with the request data using the binder of the makross, and then validates
+// it with the validator of the makross. If the validator returns ValidationErrors, a 422 (Unprocessable Entity)
+// HTTPError is returned, whose "errors" extension lists the field errors with messages translated by the
+// Localer of the context.
 func (c *Context) Bind(i interface{}) error {
-	return c.makross.binder.Bind(i, c)
+	if err := c.makross.binder.Bind(i, c); err != nil {
+		return err
+	}
+	if c.makross.validator == nil {
+		return nil
+	}
+	err := c.makross.validator.Validate(i)
+	if errs, ok := err.(ValidationErrors); ok {
+		errs.translate(c.Localer)
+		return NewHTTPError(StatusUnprocessableEntity, errs.Error()).WithExtension("errors", errs)
+	}
+	return err
 }
 
 func (c *Context) UserAgent() string {
@@ -369,9 +429,17 @@ func (c *Context) Break(status int, err ...error) error {
 // The parameters should be given in the sequence of name1, value1, name2, value2, and so on.
 // If a parameter in the route is not provided a value, the parameter token will remain in the resulting URL.
 // Parameter values will be properly URL encoded.
+// For a route bound to a host, an absolute URL using the scheme of the current request is returned.
+// In a mounted makross, the parameters of the mount point that are not given take the values of the current request.
 // The method returns an empty string if the URL creation fails.
 func (c *Context) URL(route string, pairs ...interface{}) string {
-	if r := c.makross.namedRoutes[route]; r != nil {
+	if r := c.makross.Route(route); r != nil {
+		if c.Request != nil {
+			if params := mountParams(c.Request); len(params) > 0 {
+				pairs = append(pairs[:len(pairs):len(pairs)], params...)
+			}
+			return r.url(c.Scheme(), pairs...)
+		}
 		return r.URL(pairs...)
 	}
 	return ""
@@ -568,6 +636,37 @@ func (c *Context) XMLBlob(b []byte, status ...int) (err error) {
 	return
 }
 
+// Msgpack sends a MessagePack response with the given status (200 by default).
+func (c *Context) Msgpack(i interface{}, status ...int) (err error) {
+	var code int
+	if len(status) > 0 {
+		code = status[0]
+	} else {
+		code = StatusOK
+	}
+	b, err := msgpack.Marshal(i)
+	if err != nil {
+		return err
+	}
+	return c.Blob(MIMEApplicationMsgpack, b, code)
+}
+
+// Protobuf sends a protocol buffer response with the given status (200 by default).
+// The message is encoded by ProtobufMarshal.
+func (c *Context) Protobuf(i interface{}, status ...int) (err error) {
+	var code int
+	if len(status) > 0 {
+		code = status[0]
+	} else {
+		code = StatusOK
+	}
+	b, err := ProtobufMarshal(i)
+	if err != nil {
+		return err
+	}
+	return c.Blob(MIMEApplicationProtobuf, b, code)
+}
+
 func (c *Context) Blob(contentType string, b []byte, status ...int) (err error) {
 	var code int
 	if len(status) > 0 {
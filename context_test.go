@@ -63,6 +63,19 @@ func TestContextGetSet(t *testing.T) {
 	assert.Equal(t, 123, c.Get("xyz").(int))
 }
 
+func TestContextTemplateData(t *testing.T) {
+	m := New()
+	c := m.NewContext(nil, nil)
+	c.Set("abc", "123")
+	assert.Equal(t, map[string]interface{}{"abc": "123"}, c.TemplateData())
+
+	c.Flash = &Flash{}
+	data := c.TemplateData()
+	assert.Equal(t, "123", data["abc"])
+	assert.Equal(t, c.Flash, data["Flash"])
+	assert.Nil(t, c.Get("Flash"), "the data of the context is left unchanged")
+}
+
 func TestContextQueryForm(t *testing.T) {
 	req, _ := http.NewRequest("POST", "http://www.google.com/search?q=foo&q=bar&both=x&prio=1&empty=not",
 		strings.NewReader("z=post&both=y&prio=2&empty="))
@@ -0,0 +1,148 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Formats supported by Makross.DumpRoutes.
+const (
+	DumpTable = "table"
+	DumpTree  = "tree"
+	DumpJSON  = "json"
+)
+
+// RouteInfo describes a registered route and the handlers it runs.
+type RouteInfo struct {
+	Method   string   `json:"method"`
+	Host     string   `json:"host,omitempty"`
+	Path     string   `json:"path"`
+	Name     string   `json:"name,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Handlers []string `json:"handlers"`
+}
+
+// HandlerName returns the name of the function implementing the given handler,
+// such as "github.com/insionng/makross.NotFoundHandler".
+func HandlerName(h Handler) string {
+	if h == nil {
+		return "<nil>"
+	}
+	if f := runtime.FuncForPC(reflect.ValueOf(h).Pointer()); f != nil {
+		return f.Name()
+	}
+	return "<unknown>"
+}
+
+// HandlerNames returns the names of all handlers run for the route,
+// including the ones inherited from its group and the makross, in the order they are called.
+func (r *Route) HandlerNames() []string {
+	names := make([]string, len(r.handlers))
+	for i, h := range r.handlers {
+		names[i] = HandlerName(h)
+	}
+	return names
+}
+
+// RouteInfos describes the routes of the makross in the order they were added,
+// followed by the routes of the applications mounted onto it. The handlers of a mounted route
+// include the ones run by the parent makross before delegating to the mounted application.
+func (m *Makross) RouteInfos() []RouteInfo {
+	infos := []RouteInfo{}
+	for _, route := range m.Routes() {
+		info := RouteInfo{
+			Method:   route.method,
+			Host:     route.Host(),
+			Path:     m.mountTemplate() + route.Path(),
+			Name:     route.name,
+			Handlers: route.HandlerNames(),
+		}
+		for _, tag := range route.tags {
+			info.Tags = append(info.Tags, fmt.Sprint(tag))
+		}
+		infos = append(infos, info)
+	}
+	for _, child := range m.mounts {
+		chain := make([]string, len(child.mountChain))
+		for i, h := range child.mountChain {
+			chain[i] = HandlerName(h)
+		}
+		for _, info := range child.RouteInfos() {
+			info.Handlers = append(chain[:len(chain):len(chain)], info.Handlers...)
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// DumpRoutes describes the routes of the makross in the given format, which is useful for
+// finding out which handlers, including middlewares, are run for each route.
+// The format can be DumpTable, DumpTree (the radix trees used to match the routes, per method), or DumpJSON.
+func (m *Makross) DumpRoutes(format string) (string, error) {
+	switch format {
+	case DumpTable:
+		return dumpTable(m.RouteInfos()), nil
+	case DumpTree:
+		return m.dumpTree(), nil
+	case DumpJSON:
+		b, err := json.MarshalIndent(m.RouteInfos(), "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return "", ErrUnknownDumpFormat
+}
+
+func dumpTable(infos []RouteInfo) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tNAME\tTAGS\tHANDLERS")
+	for _, info := range infos {
+		handlers := make([]string, len(info.Handlers))
+		for i, name := range info.Handlers {
+			// the package path is omitted to keep the table readable
+			handlers[i] = name[strings.LastIndex(name, "/")+1:]
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", info.Method, info.Host+info.Path, orDash(info.Name),
+			orDash(strings.Join(info.Tags, ", ")), strings.Join(handlers, " -> "))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+func (m *Makross) dumpTree() string {
+	t := m.currentTable()
+	var buf bytes.Buffer
+	dump := func(stores map[string]routeStore, suffix string) {
+		methods := make([]string, 0, len(stores))
+		for method := range stores {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			fmt.Fprintf(&buf, "%v%v\n%v", method, suffix, stores[method].String())
+		}
+	}
+	dump(t.stores, "")
+	dump(t.hostStores, " (host-bound)")
+	for _, child := range m.mounts {
+		fmt.Fprintf(&buf, "mounted at %v\n%v", child.mountTemplate(), child.dumpTree())
+	}
+	return buf.String()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func dumpMiddleware(c *Context) error {
+	return c.Next()
+}
+
+func dumpHandler(c *Context) error {
+	return c.String("ok")
+}
+
+func TestHandlerName(t *testing.T) {
+	assert.Equal(t, "github.com/insionng/makross.NotFoundHandler", HandlerName(NotFoundHandler))
+	assert.Equal(t, "<nil>", HandlerName(nil))
+}
+
+func TestDumpRoutes(t *testing.T) {
+	m := New()
+	m.Use(dumpMiddleware)
+	api := m.Group("/api")
+	api.Get("/users/<id:int>", dumpHandler).Name("user").Tag("users")
+	m.Host("admin.example.com").Post("/login", dumpHandler)
+	child := New()
+	child.Get("/invoices", dumpHandler)
+	m.Mount("/billing", child)
+
+	infos := m.RouteInfos()
+	// the mount adds a route for each method with and without the trailing wildcard
+	if assert.Len(t, infos, 21) {
+		assert.Equal(t, RouteInfo{
+			Method: "GET",
+			Path:   "/api/users/<id:int>",
+			Name:   "user",
+			Tags:   []string{"users"},
+			Handlers: []string{
+				"github.com/insionng/makross.dumpMiddleware",
+				"github.com/insionng/makross.dumpHandler",
+			},
+		}, infos[0])
+		assert.Equal(t, "admin.example.com", infos[1].Host)
+		assert.Equal(t, "/billing/*", infos[2+9].Path)
+		assert.Equal(t, "/billing/invoices", infos[20].Path)
+		assert.Equal(t, []string{
+			"github.com/insionng/makross.dumpMiddleware",
+			"github.com/insionng/makross.dumpHandler",
+		}, infos[20].Handlers)
+	}
+
+	s, err := m.DumpRoutes(DumpTable)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	assert.Len(t, lines, 22)
+	assert.True(t, strings.HasPrefix(lines[0], "METHOD"))
+	assert.Contains(t, lines[1], "/api/users/<id:int>")
+	assert.Contains(t, lines[1], "makross.dumpMiddleware -> makross.dumpHandler")
+	assert.Contains(t, lines[2], "admin.example.com/login")
+
+	s, err = m.DumpRoutes(DumpJSON)
+	assert.Nil(t, err)
+	var decoded []RouteInfo
+	assert.Nil(t, json.Unmarshal([]byte(s), &decoded))
+	assert.Equal(t, infos, decoded)
+
+	s, err = m.DumpRoutes(DumpTree)
+	assert.Nil(t, err)
+	assert.Contains(t, s, "GET\n")
+	assert.Contains(t, s, "POST (host-bound)\n")
+	assert.Contains(t, s, "mounted at /billing\n")
+
+	_, err = m.DumpRoutes("yaml")
+	assert.Equal(t, ErrUnknownDumpFormat, err)
+}
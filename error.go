@@ -18,13 +18,26 @@ var (
 	ErrRendererNotRegistered       = errors.New("renderer not registered")
 	ErrInvalidRedirectCode         = errors.New("invalid redirect status code")
 	ErrCookieNotFound              = errors.New("cookie not found")
+	ErrUnknownDumpFormat           = errors.New("unknown route dump format")
+	ErrStreamingUnsupported        = errors.New("streaming not supported by the response writer")
+	ErrStreamClosed                = errors.New("stream closed")
+	ErrNotProtoMessage             = errors.New("value is not a protocol buffer message")
+	ErrUnsupportedStreamSource     = errors.New("stream source must be a channel or a JSONStreamFunc")
 )
 
 // Error contains the error information reported by calling Context.Error().
 // HTTPError represents an error that occurred while handling a request.
+// The optional Type, Title, Detail, Instance and Extensions are the members of the
+// RFC 7807 problem details written by Context.Problem.
 type HTTPError struct {
 	Status  int    //`json:"status" xml:"status"`
 	Message string //`json:"message" xml:"message"`
+
+	Type       string                 `json:",omitempty"`
+	Title      string                 `json:",omitempty"`
+	Detail     string                 `json:",omitempty"`
+	Instance   string                 `json:",omitempty"`
+	Extensions map[string]interface{} `json:",omitempty"`
 }
 
 // NewHTTPError creates a new HTTPError instance.
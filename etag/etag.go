@@ -0,0 +1,159 @@
+package etag
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/insionng/makross"
+	"github.com/insionng/makross/skipper"
+)
+
+type (
+	// ETagConfig defines the config for ETag middleware.
+	ETagConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper skipper.Skipper
+
+		// Weak marks the generated ETags as weak validators.
+		// Optional. Default value false.
+		Weak bool `json:"weak"`
+
+		// MaxSize is the maximum size in bytes of a response body buffered to compute its ETag.
+		// Larger responses, and responses flushed by the handler, are streamed without an ETag.
+		// Optional. Default value 1 MB.
+		MaxSize int `json:"max_size"`
+	}
+
+	// bufferWriter buffers a response until it is complete, unless it grows too large or is flushed.
+	bufferWriter struct {
+		http.ResponseWriter
+		buf         bytes.Buffer
+		status      int
+		maxSize     int
+		passthrough bool
+	}
+)
+
+var (
+	// DefaultETagConfig is the default ETag middleware config.
+	DefaultETagConfig = ETagConfig{
+		Skipper: skipper.DefaultSkipper,
+		MaxSize: 1 << 20,
+	}
+)
+
+// ETag returns a middleware which adds ETags to responses and answers conditional requests.
+//
+// ETag middleware buffers the successful responses of GET and HEAD requests, sets the ETag header
+// to a hash of the body unless the handler has already set one with Context.SetETag, and replaces
+// the response with "304 - Not Modified" when the If-None-Match or If-Modified-Since headers
+// of the request show that the client's copy is fresh.
+func ETag() makross.Handler {
+	return ETagWithConfig(DefaultETagConfig)
+}
+
+// ETagWithConfig returns an ETag middleware with config.
+// See: `ETag()`.
+func ETagWithConfig(config ETagConfig) makross.Handler {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultETagConfig.Skipper
+	}
+	if config.MaxSize == 0 {
+		config.MaxSize = DefaultETagConfig.MaxSize
+	}
+
+	return func(c *makross.Context) error {
+		if config.Skipper(c) || c.Request.Method != makross.GET && c.Request.Method != makross.HEAD {
+			return c.Next()
+		}
+
+		res := c.Response
+		rw := res.Writer
+		bw := &bufferWriter{ResponseWriter: rw, maxSize: config.MaxSize}
+		res.Writer = bw
+		err := c.Next()
+		res.Writer = rw
+		if bw.passthrough || bw.status == 0 {
+			return err
+		}
+
+		// replay the buffered response
+		res.Committed, res.Size = false, 0
+		if bw.status == makross.StatusOK && err == nil {
+			if res.Header().Get(makross.HeaderETag) == "" {
+				sum := sha256.Sum256(bw.buf.Bytes())
+				c.SetETag(hex.EncodeToString(sum[:16]), config.Weak)
+			}
+			if c.NotModified() {
+				return nil
+			}
+		}
+		res.WriteHeader(bw.status)
+		if _, werr := res.Write(bw.buf.Bytes()); err == nil {
+			err = werr
+		}
+		return err
+	}
+}
+
+func (w *bufferWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	if !w.passthrough && w.buf.Len()+len(b) > w.maxSize {
+		w.stream()
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+// stream writes the buffered response and passes the rest of it through.
+func (w *bufferWriter) stream() {
+	if w.passthrough {
+		return
+	}
+	w.passthrough = true
+	if w.status == 0 {
+		w.status = makross.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+}
+
+func (w *bufferWriter) Flush() {
+	w.stream()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *bufferWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("etag: the response writer does not support hijacking")
+	}
+	w.passthrough = true
+	return h.Hijack()
+}
+
+// CloseNotify returns a channel that never receives if the response writer cannot notify it.
+func (w *bufferWriter) CloseNotify() <-chan bool {
+	if n, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return n.CloseNotify()
+	}
+	return make(chan bool)
+}
@@ -0,0 +1,115 @@
+package etag
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/insionng/makross"
+	"github.com/stretchr/testify/assert"
+)
+
+func request(m *makross.Makross, method, path string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestETag(t *testing.T) {
+	m := makross.New()
+	m.Use(ETag())
+	m.Get("/", func(c *makross.Context) error {
+		return c.JSON(map[string]string{"name": "makross"})
+	})
+	m.Get("/missing", func(c *makross.Context) error {
+		return c.String("missing", makross.StatusNotFound)
+	})
+	m.Get("/custom", func(c *makross.Context) error {
+		c.SetETag("v1")
+		return c.String("custom")
+	})
+	m.Post("/", func(c *makross.Context) error {
+		return c.String("created", makross.StatusCreated)
+	})
+
+	rec := request(m, makross.GET, "/")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"name":"makross"}`, rec.Body.String())
+	etag := rec.Header().Get(makross.HeaderETag)
+	assert.Len(t, etag, 34)
+
+	rec = request(m, makross.GET, "/", makross.HeaderIfNoneMatch, etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, "", rec.Body.String())
+	assert.Equal(t, "", rec.Header().Get(makross.HeaderContentType))
+	assert.Equal(t, etag, rec.Header().Get(makross.HeaderETag))
+
+	rec = request(m, makross.GET, "/", makross.HeaderIfNoneMatch, `"other"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = request(m, makross.GET, "/custom", makross.HeaderIfNoneMatch, `"v0", W/"v1"`)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, `"v1"`, rec.Header().Get(makross.HeaderETag))
+
+	rec = request(m, makross.GET, "/missing")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "missing", rec.Body.String())
+	assert.Equal(t, "", rec.Header().Get(makross.HeaderETag))
+
+	rec = request(m, makross.POST, "/")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "", rec.Header().Get(makross.HeaderETag))
+}
+
+func TestETagWithConfig(t *testing.T) {
+	m := makross.New()
+	m.Use(ETagWithConfig(ETagConfig{Weak: true, MaxSize: 10}))
+	m.Get("/small", func(c *makross.Context) error {
+		return c.String("small")
+	})
+	m.Get("/large", func(c *makross.Context) error {
+		return c.String(strings.Repeat("x", 11))
+	})
+	m.Get("/flushed", func(c *makross.Context) error {
+		c.Response.Write([]byte("a"))
+		c.Response.Flush()
+		c.Response.Write([]byte("b"))
+		return nil
+	})
+
+	rec := request(m, makross.GET, "/small")
+	assert.Equal(t, "small", rec.Body.String())
+	assert.True(t, strings.HasPrefix(rec.Header().Get(makross.HeaderETag), `W/"`))
+	assert.Equal(t, int64(5), int64(rec.Body.Len()))
+
+	rec = request(m, makross.GET, "/large")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, strings.Repeat("x", 11), rec.Body.String())
+	assert.Equal(t, "", rec.Header().Get(makross.HeaderETag))
+
+	rec = request(m, makross.GET, "/flushed")
+	assert.Equal(t, "ab", rec.Body.String())
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, "", rec.Header().Get(makross.HeaderETag))
+}
+
+func TestETagWriterInterfaces(t *testing.T) {
+	// the response writer of httptest supports neither hijacking nor close notifications
+	w := &bufferWriter{ResponseWriter: httptest.NewRecorder()}
+	conn, rw, err := w.Hijack()
+	assert.Nil(t, conn)
+	assert.Nil(t, rw)
+	assert.Error(t, err)
+	assert.False(t, w.passthrough)
+
+	select {
+	case <-w.CloseNotify():
+		t.Error("the close notification should never be sent")
+	default:
+	}
+}
@@ -159,13 +159,36 @@ func getContext(templateData interface{}) map[string]interface{} {
 }
 */
 
+// templateData returns the context data femplate is able to render. Flash messages, including
+// the ones of ctx.Flash, are exposed as "Flash.<category>", other values of unsupported types are skipped.
+func templateData(ctx *makross.Context) map[string]interface{} {
+	store := ctx.TemplateData()
+	data := make(map[string]interface{}, len(store))
+	for k, v := range store {
+		switch value := v.(type) {
+		case string, []byte, femplate.TagFunc:
+			data[k] = v
+		case *makross.Flash:
+			addFlash(data, k, value)
+		}
+	}
+	return data
+}
+
+// addFlash exposes the messages of the flash as "<key>.<category>".
+func addFlash(data map[string]interface{}, key string, flash *makross.Flash) {
+	for _, category := range flash.Categories() {
+		data[key+"."+category] = flash.Message(category)
+	}
+}
+
 func (r *Renderer) Render(w io.Writer, name string, ctx *makross.Context) error {
 	template, err := r.getTemplate(name)
 	if err != nil {
 		return err
 	}
 
-	if b := []byte(template.ExecuteString(ctx.GetStore())); r.Filter {
+	if b := []byte(template.ExecuteString(templateData(ctx))); r.Filter {
 		_, err = fmt.Fprintf(w, "%s", ctx.DoFilterHook(fmt.Sprintf("%s_template", name), func() []byte {
 			return b
 		}))
@@ -94,7 +94,7 @@ func (r *Renderer) Render(w io.Writer, name string, c *makross.Context) (err err
 	template.Delims(r.DelimLeft, r.DelimRight)
 
 	var buffer bytes.Buffer
-	err = template.Execute(&buffer, c.GetStore())
+	err = template.Execute(&buffer, c.TemplateData())
 	if err != nil {
 		return err
 	}
@@ -2,13 +2,21 @@
 
 package makross
 
-import "strings"
+import (
+	"net/http"
+	"strings"
+)
 
 // RouteGroup represents a group of routes that share the same path prefix.
+// A group may also be bound to a host pattern, in which case its routes only match requests for that host.
 type RouteGroup struct {
-	prefix   string
-	makross  *Makross
-	handlers []Handler
+	host         string
+	prefix       string
+	makross      *Makross
+	handlers     []Handler
+	parent       *RouteGroup
+	errorHandler ErrorHandler
+	update       *RouteUpdate // the update the group adds its routes to, nil for the current routing table
 }
 
 // newRouteGroup creates a new RouteGroup with the given path prefix, makross, and handlers.
@@ -25,6 +33,36 @@ func (rg *RouteGroup) SetRenderer(r Renderer) {
 	rg.makross.renderer = r
 }
 
+// SetErrorHandler sets the handler of the errors returned by the routes of the group and its subgroups.
+// The subgroups may set their own handlers, and the groups without one fall back to the handler
+// of their parent group, and finally to the one set by Makross.SetErrorHandler.
+func (rg *RouteGroup) SetErrorHandler(h ErrorHandler) {
+	rg.errorHandler = h
+}
+
+// findErrorHandler returns the error handler of the group or of its closest ancestor, or nil if there is none.
+func (rg *RouteGroup) findErrorHandler() ErrorHandler {
+	for g := rg; g != nil; g = g.parent {
+		if g.errorHandler != nil {
+			return g.errorHandler
+		}
+	}
+	return nil
+}
+
+// withTable calls fn with the routing table the routes of the group are added to: the table being built
+// by the update of the group or by the update in progress, in which case pending is true, or the current one.
+func (rg *RouteGroup) withTable(fn func(t *routeTable, pending bool)) {
+	for _, u := range []*RouteUpdate{rg.update, rg.makross.pendingUpdate()} {
+		if u != nil && u.apply(func(t *routeTable) { fn(t, true) }) {
+			return
+		}
+	}
+	rg.makross.mu.Lock()
+	defer rg.makross.mu.Unlock()
+	fn(rg.makross.routeTable, false)
+}
+
 // Get adds a GET route to the makross with the given route path and handlers.
 func (rg *RouteGroup) Get(path string, handlers ...Handler) *Route {
 	return rg.add("GET", path, handlers)
@@ -99,7 +137,50 @@ func (rg *RouteGroup) Group(prefix string, handlers ...Handler) *RouteGroup {
 		handlers = make([]Handler, len(rg.handlers))
 		copy(handlers, rg.handlers)
 	}
-	return newRouteGroup(rg.prefix+prefix, rg.makross, handlers)
+	g := newRouteGroup(rg.prefix+prefix, rg.makross, handlers)
+	g.host = rg.host
+	g.parent = rg
+	g.update = rg.update
+	return g
+}
+
+// Host creates a RouteGroup whose routes only match requests for the given host pattern.
+// The pattern may contain parameter tokens such as "<tenant>.example.com"; a token without
+// a regular expression matches a single host label, and its value can be read by Context.Param
+// in the same way as path parameters. The port of the requested host is ignored.
+// If no handler is provided, the new group will inherit the handlers registered
+// with the current group.
+func (rg *RouteGroup) Host(host string, handlers ...Handler) *RouteGroup {
+	if len(handlers) == 0 {
+		handlers = make([]Handler, len(rg.handlers))
+		copy(handlers, rg.handlers)
+	}
+	g := newRouteGroup(rg.prefix, rg.makross, handlers)
+	g.host = strings.ToLower(host)
+	g.parent = rg
+	g.update = rg.update
+	return g
+}
+
+// Mount delegates all requests whose path starts with the given prefix to the handler.
+// The prefix is stripped from the request path before the handler is called, and the handlers
+// registered with the current group are executed in advance.
+// If the handler is a *Makross, it keeps its own handlers, not-found handling and named routes,
+// and the URLs it creates will include the mount point, filled with the parameters of the request
+// being served. Its named routes can also be found via the Route method of the makross it is mounted to.
+func (rg *RouteGroup) Mount(prefix string, h http.Handler) {
+	prefix = strings.TrimRight(prefix, "/")
+	if child, ok := h.(*Makross); ok {
+		child.parent = rg.makross
+		child.mountPrefix = buildURLTemplate(rg.prefix + prefix)
+		child.mountHost = rg.host
+		child.mountChain = rg.handlers
+		rg.makross.mounts = append(rg.makross.mounts, child)
+	}
+	if rg.prefix+prefix != "" {
+		rg.Any(prefix, mountHandler(h, false))
+	}
+	rg.Any(prefix+"/*", mountHandler(h, true))
 }
 
 // Use registers one or multiple handlers to the current route group.
@@ -159,3 +240,23 @@ func buildURLTemplate(path string) string {
 	}
 	return template
 }
+
+// buildHostPattern converts a host pattern into a store key by restricting the parameter tokens
+// that have no regular expression to a single host label.
+func buildHostPattern(host string) string {
+	pattern, start, end := "", -1, -1
+	for i := 0; i < len(host); i++ {
+		if host[i] == '<' && start < 0 {
+			start = i
+		} else if host[i] == '>' && start >= 0 {
+			token := host[start+1 : i]
+			if !strings.Contains(token, ":") {
+				token += `:[^./]+`
+			}
+			pattern += host[end+1:start] + "<" + token + ">"
+			end = i
+			start = -1
+		}
+	}
+	return pattern + host[end+1:]
+}
@@ -4,6 +4,8 @@ package makross
 
 import (
 	"bytes"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -89,3 +91,109 @@ func TestRouteGroupUse(t *testing.T) {
 	group2.Use(newHandler("3", &buf))
 	assert.Equal(t, 3, len(group2.handlers), "len(group2.handlers) =")
 }
+
+func TestRouteGroupHost(t *testing.T) {
+	m := New()
+	api := m.Host("API.example.com")
+	assert.Equal(t, "api.example.com", api.host, "api.host =")
+	users := api.Group("/users")
+	assert.Equal(t, "api.example.com", users.host, "users.host =")
+	assert.Equal(t, "/users", users.prefix, "users.prefix =")
+
+	var buf bytes.Buffer
+	g := newRouteGroup("/admin", New(), []Handler{newHandler("1", &buf)})
+	assert.Equal(t, 1, len(g.Host("admin.example.com").handlers), "len(handlers) =")
+	assert.Equal(t, 2, len(g.Host("admin.example.com", newHandler("2", &buf), newHandler("3", &buf)).handlers), "len(handlers) =")
+}
+
+func TestBuildHostPattern(t *testing.T) {
+	tests := []struct {
+		host, expected string
+	}{
+		{"", ""},
+		{"example.com", "example.com"},
+		{"<tenant>.example.com", "<tenant:[^./]+>.example.com"},
+		{"<tenant:\\w+>.example.com", "<tenant:\\w+>.example.com"},
+		{"<a>.<b>.example.com", "<a:[^./]+>.<b:[^./]+>.example.com"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, buildHostPattern(test.host), "buildHostPattern("+test.host+") =")
+	}
+}
+
+func TestRouteGroupMount(t *testing.T) {
+	billing := New()
+	billing.Use(func(c *Context) error {
+		c.Response.Header().Set("X-Billing", "1")
+		return nil
+	})
+	billing.Get("/", func(c *Context) error {
+		return c.String("index")
+	})
+	billing.Get("/invoices/<id>", func(c *Context) error {
+		return c.String(c.Request.URL.Path + " " + c.URL("invoice", "id", 2))
+	}).Name("invoice")
+
+	m := New()
+	m.Group("/tenants/<tenant>").Mount("/billing/", billing)
+	m.Mount("/static", http.StripPrefix("/assets", http.NotFoundHandler()))
+
+	tests := []struct {
+		path, body string
+		code       int
+	}{
+		{"/tenants/acme/billing", "index", StatusOK},
+		{"/tenants/acme/billing/", "index", StatusOK},
+		{"/tenants/acme/billing/invoices/1", "/invoices/1 /tenants/acme/billing/invoices/2", StatusOK},
+		{"/tenants/acme/billing/unknown", "Not Found", StatusNotFound},
+		{"/tenants/acme/billingx", "Not Found", StatusNotFound},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		m.ServeHTTP(res, req)
+		assert.Equal(t, test.code, res.Code, test.path)
+		assert.Equal(t, test.body, res.Body.String(), test.path)
+		if test.path != "/tenants/acme/billingx" {
+			assert.Equal(t, "1", res.Header().Get("X-Billing"), test.path)
+		}
+	}
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tenants/acme/billing/invoices/1", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusMethodNotAllowed, res.Code)
+	assert.Equal(t, "GET, OPTIONS", res.Header().Get("Allow"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/static/app.js", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusNotFound, res.Code)
+
+	if r := m.Route("invoice"); assert.NotNil(t, r) {
+		assert.Equal(t, "/tenants/acme/billing/invoices/3", r.URL("tenant", "acme", "id", 3))
+	}
+}
+
+func TestRouteGroupMountParams(t *testing.T) {
+	reports := New()
+	reports.Get("/<year>", func(c *Context) error {
+		return c.String(c.URL("report", "year", 2017) + " " + c.URL("report", "org", "other", "year", 2018))
+	}).Name("report")
+	billing := New()
+	billing.Group("/orgs/<org>").Mount("/reports", reports)
+
+	m := New()
+	m.Host("<tenant>.example.com").Mount("/billing", billing)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://acme.example.com/billing/orgs/42/reports/2016", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusOK, res.Code)
+	assert.Equal(t, "http://acme.example.com/billing/orgs/42/reports/2017 http://acme.example.com/billing/orgs/other/reports/2018", res.Body.String())
+
+	if r := m.Route("report"); assert.NotNil(t, r) {
+		assert.Equal(t, "<tenant>.example.com", r.Host())
+		assert.Equal(t, "http://a.example.com/billing/orgs/1/reports/2", r.URL("tenant", "a", "org", 1, "year", 2))
+	}
+}
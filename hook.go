@@ -2,373 +2,482 @@ package makross
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/insionng/prior"
 )
 
+type (
+	// EventHook is called when the event it is added for occurs. c is the context of the request
+	// the event belongs to and err the error being handled for HookError; both may be nil.
+	EventHook func(c *Context, err error)
+
+	// Hook is the handle of a hook added to a Makross or a Context, which can be used to remove it.
+	Hook struct {
+		key      string
+		priority int
+		seq      uint64
+		filter   func([]byte) []byte
+		event    EventHook
+		set      *hookSet
+		removed  int32
+	}
+
+	// hookSet stores hooks by key, sorted by priority. It is safe for concurrent use: the slice of
+	// hooks of a key is replaced rather than modified, so hooks run without holding the lock and may
+	// add or remove hooks themselves.
+	hookSet struct {
+		mu    sync.RWMutex
+		seq   uint64
+		hooks map[string][]*Hook
+	}
+)
+
+// Lifecycle events, which can be hooked with AddEventHook, or with AddActionHook if the context is not needed.
+const (
+	HookListen         = "MakrossListen"         // the server is about to listen
+	HookListenTLS      = "MakrossListenTLS"      // the TLS server is about to listen
+	HookRequestStart   = "MakrossRequestStart"   // a request is received, before it is routed
+	HookRouteMatched   = "MakrossRouteMatched"   // a route matching the request is found
+	HookBeforeResponse = "MakrossBeforeResponse" // the response header is about to be written
+	HookError          = "MakrossError"          // an error returned by the handlers is about to be handled
+	HookShutdown       = "MakrossShutdown"       // the server is being shut down gracefully
+	HookClose          = "MakrossClose"          // the server is being closed
+)
+
 var (
 	// DefaultPriority 默认优先级为0值
 	DefaultPriority int
-	callback        func([]byte) []byte
 )
 
-// NewPriorityQueue New PriorityQueue
-func (m *Makross) NewPriorityQueue() *prior.PriorityQueue {
-	return prior.NewPriorityQueue()
+// Key returns the key the hook is added for.
+func (h *Hook) Key() string {
+	return h.key
 }
 
-func (m *Makross) NewNode(key interface{}, v interface{}, priority int) *prior.Node {
-	return prior.NewNode(key, v, priority)
+// Priority returns the priority of the hook.
+func (h *Hook) Priority() int {
+	return h.priority
 }
 
-// NewPriorityQueue New PriorityQueue
-func (c *Context) NewPriorityQueue() *prior.PriorityQueue {
-	return c.makross.NewPriorityQueue()
+// Remove removes the hook. It returns false if the hook has been removed already.
+func (h *Hook) Remove() bool {
+	if h.set == nil {
+		return false
+	}
+	return h.set.remove(h)
 }
 
-func (c *Context) NewNode(key interface{}, v interface{}, priority int) *prior.Node {
-	return prior.NewNode(key, v, priority)
+func newHook(key string, priorities []int) *Hook {
+	h := &Hook{key: key, priority: DefaultPriority}
+	if len(priorities) > 0 {
+		h.priority = priorities[0]
+	}
+	return h
 }
 
-// SetPriorityQueueWith c.makross.QueuesMap[key] = c.NewPriorityQueue()
-func (m *Makross) SetPriorityQueueWith(key interface{}) *sync.Map {
-	if m.QueuesMap == nil {
-		m.QueuesMap = new(sync.Map)
-	}
-	m.QueuesMap.Store(key, m.NewPriorityQueue())
-	return m.QueuesMap
+func newFilterHook(key string, function func([]byte) []byte, priorities []int) *Hook {
+	h := newHook(key, priorities)
+	h.filter = function
+	return h
 }
 
-// SetPriorityQueueWith c.makross.QueuesMap[key] = c.NewPriorityQueue()
-func (c *Context) SetPriorityQueueWith(key interface{}) *sync.Map {
-	return c.makross.SetPriorityQueueWith(key)
+func newActionHook(key string, function func(), priorities []int) *Hook {
+	return newFilterHook(key, func(b []byte) []byte {
+		function()
+		return b
+	}, priorities)
 }
 
-// RemoveFilterHook (m *Makross) 删除过滤钩子
-func (m *Makross) RemoveFilterHook(key string) {
-	if m.HasFilterHook(key) {
-		m.FiltersMap.Delete(key)
-	}
+func newEventHook(key string, function EventHook, priorities []int) *Hook {
+	h := newHook(key, priorities)
+	h.event = function
+	return h
 }
 
-// RemoveFilterHook (c *Context) 删除过滤钩子
-func (c *Context) RemoveFilterHook(key string, globals ...bool) {
-	var global = false
-	if len(globals) > 0 {
-		global = globals[0]
+// add adds the hook after the hooks of the same key whose priority is not greater.
+func (s *hookSet) add(h *Hook) *Hook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hooks == nil {
+		s.hooks = make(map[string][]*Hook)
 	}
-	if global {
-		c.makross.RemoveFilterHook(key)
-	} else if c.HasFilterHook(key) {
-		c.FiltersMap.Delete(key)
+	s.seq++
+	h.seq = s.seq
+	h.set = s
+	old := s.hooks[h.key]
+	i := len(old)
+	for i > 0 && old[i-1].priority > h.priority {
+		i--
 	}
+	hooks := make([]*Hook, 0, len(old)+1)
+	hooks = append(hooks, old[:i]...)
+	hooks = append(hooks, h)
+	s.hooks[h.key] = append(hooks, old[i:]...)
+	return h
 }
 
-// RemoveActionHook (m *Makross) 删除动作钩子
-func (m *Makross) RemoveActionHook(key string) {
-	if m.HasActionHook(key) {
-		m.QueuesMap.Delete(key)
-		m.FiltersMap.Delete(key)
+func (s *hookSet) remove(h *Hook) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.hooks[h.key]
+	for i, hook := range old {
+		if hook == h {
+			atomic.StoreInt32(&h.removed, 1)
+			if len(old) == 1 {
+				delete(s.hooks, h.key)
+			} else {
+				hooks := make([]*Hook, 0, len(old)-1)
+				hooks = append(hooks, old[:i]...)
+				s.hooks[h.key] = append(hooks, old[i+1:]...)
+			}
+			return true
+		}
 	}
+	return false
 }
 
-// RemoveActionHook (c *Context) 删除动作钩子
-func (c *Context) RemoveActionHook(key string, globals ...bool) {
-
-	var global = false
-	if len(globals) > 0 {
-		global = globals[0]
+func (s *hookSet) removeKey(key string) {
+	if s == nil {
+		return
 	}
-	if global {
-		c.makross.RemoveActionHook(key)
-	} else if c.HasActionHook(key) {
-		c.makross.QueuesMap.Delete(key)
-		c.FiltersMap.Delete(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, h := range s.hooks[key] {
+		atomic.StoreInt32(&h.removed, 1)
 	}
+	delete(s.hooks, key)
 }
 
-// RemoveActionsHook (m *Makross) 删除所有动作钩子
-func (m *Makross) RemoveActionsHook() {
-	m.QueuesMap = nil
-}
-
-// RemoveActionsHook (c *Context) 删除所有动作钩子
-func (c *Context) RemoveActionsHook() {
-	c.makross.RemoveActionsHook()
+func (s *hookSet) removeAll() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, hooks := range s.hooks {
+		for _, h := range hooks {
+			atomic.StoreInt32(&h.removed, 1)
+		}
+	}
+	s.hooks = nil
 }
 
-// HasFilterHook (m *Makross) 是否有过滤钩子
-func (m *Makross) HasFilterHook(key string) bool {
-	if _, okay := m.FiltersMap.Load(key); okay {
-		return true
+// get returns the hooks of the given key in the order they are run. The slice must not be modified.
+func (s *hookSet) get(key string) []*Hook {
+	if s == nil {
+		return nil
 	}
-	return false
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hooks[key]
 }
 
-// HasFilterHook (c *Context) 是否有过滤钩子
-func (c *Context) HasFilterHook(key string, globals ...bool) bool {
+func (s *hookSet) has(key string) bool {
+	return len(s.get(key)) > 0
+}
 
-	var global = false
-	if len(globals) > 0 {
-		global = globals[0]
+// mergeHooks merges two sorted lists of hooks. Hooks of a with the same priority as hooks of b run first.
+func mergeHooks(a, b []*Hook) []*Hook {
+	if len(a) == 0 {
+		return b
 	}
-	if global {
-		return c.makross.HasFilterHook(key)
+	if len(b) == 0 {
+		return a
 	}
-	if c.FiltersMap != nil {
-		if _, okay := c.FiltersMap.Load(key); okay {
-			return true
+	hooks := make([]*Hook, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if b[0].priority < a[0].priority {
+			hooks = append(hooks, b[0])
+			b = b[1:]
+		} else {
+			hooks = append(hooks, a[0])
+			a = a[1:]
 		}
 	}
-
-	return false
+	hooks = append(hooks, a...)
+	return append(hooks, b...)
 }
 
-// HasQueuesMap (m *Makross) Has QueuesMap
-func (m *Makross) HasQueuesMap(key string) bool {
-	if value, okay := m.QueuesMap.Load(key); okay {
-		if pqueue, okay := value.(*prior.PriorityQueue); okay {
-			if pqueue.Length() > 0 {
-				return true
-			}
+// runHooks runs the hooks in order. Filter and action hooks are passed the bytes returned by the previous one,
+// event hooks the context and the error. Hooks removed while running are skipped.
+func runHooks(hooks []*Hook, c *Context, err error, b []byte) []byte {
+	for _, h := range hooks {
+		if atomic.LoadInt32(&h.removed) != 0 {
+			continue
+		}
+		if h.event != nil {
+			h.event(c, err)
+		} else {
+			b = h.filter(b)
 		}
 	}
-	return false
+	return b
 }
 
-// HasQueuesMap (c *Context) Has QueuesMap
-func (c *Context) HasQueuesMap(key string) bool {
-	return c.makross.HasQueuesMap(key)
+// AddFilter adds a filter hook for the given key, which is run by every DoFilterHook call of the key
+// until it is removed with the returned Hook or RemoveFilterHook. Hooks run in ascending order of priority,
+// DefaultPriority if none is given, and in the order they are added if their priorities are equal.
+func (m *Makross) AddFilter(key string, function func([]byte) []byte, priorities ...int) *Hook {
+	return m.hooks.add(newFilterHook(key, function, priorities))
 }
 
-// HasActionHook (m *Makross) 是否有动作钩子
-func (m *Makross) HasActionHook(key string) bool {
-	if value, okay := m.QueuesMap.Load(key); okay {
-		if _, okay := value.(*prior.PriorityQueue); okay {
-			return true
-		}
-	}
-	return false
+// AddAction adds an action hook for the given key, which is run by every DoActionHook call of the key
+// until it is removed. See AddFilter for the order in which hooks run.
+func (m *Makross) AddAction(key string, function func(), priorities ...int) *Hook {
+	return m.hooks.add(newActionHook(key, function, priorities))
 }
 
-// HasActionHook (c *Context) 是否有动作钩子
-func (c *Context) HasActionHook(key string) bool {
-	return c.makross.HasActionHook(key)
+// AddFilterHook adds a filter hook for the given key, see AddFilter.
+func (m *Makross) AddFilterHook(key string, function func([]byte) []byte, priorities ...int) {
+	m.AddFilter(key, function, priorities...)
 }
 
-// AddActionHook (m *Makross) 增加动作钩子
+// AddActionHook adds an action hook for the given key, see AddAction.
 func (m *Makross) AddActionHook(key string, function func(), priorities ...int) {
-	m.AddFilterHook(key, func([]byte) []byte {
-		function()
-		return nil
-	}, priorities...)
+	m.AddAction(key, function, priorities...)
 }
 
-// AddActionHook (c *Context) 增加动作钩子
-func (c *Context) AddActionHook(key string, function func(), priorities ...int) {
-	c.AddFilterHook(key, func([]byte) []byte {
-		function()
-		return nil
-	}, priorities...)
+// AddEventHook adds a hook for the given event, such as HookRequestStart, which is called with the context of
+// the request and the error being handled. See AddFilter for the order in which hooks run.
+func (m *Makross) AddEventHook(event string, function EventHook, priorities ...int) *Hook {
+	return m.hooks.add(newEventHook(event, function, priorities))
 }
 
-// AddFilterHook (m *Makross) 增加过滤钩子
-func (m *Makross) AddFilterHook(key string, function func([]byte) []byte, priorities ...int) {
-
-	if !m.HasQueuesMap(key) {
-		m.SetPriorityQueueWith(key)
+// DoFilterHook runs the hooks of the given key on the bytes returned by function and returns the result.
+// The result of function is returned unchanged if the key has no hooks.
+func (m *Makross) DoFilterHook(key string, function func() []byte) []byte {
+	var b []byte
+	if function != nil {
+		b = function()
+	}
+	b = runHooks(m.hooks.get(key), nil, nil, b)
+	if m.FiltersMap != nil {
+		m.FiltersMap.Store(key, b)
 	}
+	return b
+}
 
-	var priority int
-	if len(priorities) > 0 {
-		priority = priorities[0]
-	} else {
-		priority = DefaultPriority
+// DoActionHook runs the hooks of the given key.
+func (m *Makross) DoActionHook(key string) {
+	runHooks(m.hooks.get(key), nil, nil, nil)
+}
+
+// DoEventHook runs the hooks of the given event with the given context and error, either of which may be nil.
+// The hooks added to the context run as well.
+func (m *Makross) DoEventHook(event string, c *Context, err error) {
+	if c != nil {
+		c.DoEventHook(event, err)
+		return
 	}
-	pq := m.NewPriorityQueue()
-	pq.Push(prior.NewNode(key, function, priority))
-	m.QueuesMap.Store(key, pq)
+	runHooks(m.hooks.get(event), nil, err, nil)
+}
 
+// HasFilterHook returns whether the given key has hooks.
+func (m *Makross) HasFilterHook(key string) bool {
+	return m.hooks.has(key)
 }
 
-// AddFilterHook (c *Context) 增加过滤钩子
-func (c *Context) AddFilterHook(key string, function func([]byte) []byte, priorities ...int) {
+// HasActionHook returns whether the given key has hooks.
+func (m *Makross) HasActionHook(key string) bool {
+	return m.hooks.has(key)
+}
 
-	if !c.HasQueuesMap(key) {
-		c.makross.SetPriorityQueueWith(key)
-	}
+// RemoveFilterHook removes all hooks of the given key.
+func (m *Makross) RemoveFilterHook(key string) {
+	m.hooks.removeKey(key)
+}
 
-	var priority int
-	if len(priorities) > 0 {
-		priority = priorities[0]
-	} else {
-		priority = DefaultPriority
-	}
+// RemoveActionHook removes all hooks of the given key.
+func (m *Makross) RemoveActionHook(key string) {
+	m.hooks.removeKey(key)
+}
 
-	pq := c.NewPriorityQueue()
-	pq.Push(prior.NewNode(key, function, priority))
-	c.makross.QueuesMap.Store(key, pq)
+// RemoveActionsHook removes all hooks.
+func (m *Makross) RemoveActionsHook() {
+	m.hooks.removeAll()
 }
 
-// DoActionHook (m *Makross) 动作钩子
-func (m *Makross) DoActionHook(key string) {
-	m.DoFilterHook(key, nil)
+// AddFilter adds a filter hook for the given key to the context, which only applies to the current request.
+// See Makross.AddFilter for the order in which hooks run.
+func (c *Context) AddFilter(key string, function func([]byte) []byte, priorities ...int) *Hook {
+	return c.addHook(newFilterHook(key, function, priorities))
 }
 
-// DoActionHook (c *Context) 动作钩子
-func (c *Context) DoActionHook(key string, globals ...bool) {
-	c.DoFilterHook(key, nil, globals...)
+// AddAction adds an action hook for the given key to the context, which only applies to the current request.
+func (c *Context) AddAction(key string, function func(), priorities ...int) *Hook {
+	return c.addHook(newActionHook(key, function, priorities))
 }
 
-// DoFilterHook (m *Makross) 执行过滤钩子
-func (m *Makross) DoFilterHook(key string, function func() []byte) []byte {
-	if !m.HasQueuesMap(key) {
-		m.AddFilterHook(key, func(b []byte) []byte {
-			if function == nil {
-				return b
-			}
-			return function()
-		})
-		return m.DoFilterHook(key, function)
+// AddFilterHook adds a filter hook for the given key to the context, see AddFilter.
+func (c *Context) AddFilterHook(key string, function func([]byte) []byte, priorities ...int) {
+	c.AddFilter(key, function, priorities...)
+}
+
+// AddActionHook adds an action hook for the given key to the context, see AddAction.
+func (c *Context) AddActionHook(key string, function func(), priorities ...int) {
+	c.AddAction(key, function, priorities...)
+}
+
+// AddEventHook adds a hook for the given event to the context, which only applies to the current request.
+func (c *Context) AddEventHook(event string, function EventHook, priorities ...int) *Hook {
+	return c.addHook(newEventHook(event, function, priorities))
+}
+
+func (c *Context) addHook(h *Hook) *Hook {
+	if c.hooks == nil {
+		c.hooks = new(hookSet)
 	}
-	if !m.HasFilterHook(key) {
-		if m.FiltersMap == nil {
-			m.FiltersMap = new(sync.Map) //make(map[string][]byte)
-		}
+	c.hooks.add(h)
+	if h.key == HookBeforeResponse {
+		c.hookBeforeResponse()
 	}
+	return h
+}
 
-	if m.HasActionHook(key) {
-		if queue, okay := m.QueuesMap.Load(key); okay {
-			pq, okay := queue.(*prior.PriorityQueue)
-			if !okay {
-				return nil
-			}
-			for pq.Length() > 0 {
-				if node := pq.Pop(); node != nil {
-					if value := node.GetValue(); value != nil {
-						if callback, okay = value.(func([]byte) []byte); !okay {
-							return nil
-						}
-					} else {
-						continue
-					}
-				} else {
-					continue
-				}
-
-				if function != nil { //for Global Filter
-					if value, okay := m.FiltersMap.Load(key); okay {
-						if b, okay := value.([]byte); okay {
-							m.FiltersMap.Store(key, callback(b))
-						}
-					} else {
-						m.FiltersMap.Store(key, callback(function()))
-					}
-				} else { //for Global Action
-					m.FiltersMap.Store(key, callback(nil))
-				}
+// hookBeforeResponse makes the response run the HookBeforeResponse hooks before writing the header.
+func (c *Context) hookBeforeResponse() {
+	if !c.beforeResponseHooked {
+		c.beforeResponseHooked = true
+		c.Response.Before(func() {
+			c.DoEventHook(HookBeforeResponse, nil)
+		})
+	}
+}
 
-			}
-		}
+// contextHooks returns the hooks of the given key added to the makross and, unless global is true, to the context.
+func (c *Context) contextHooks(key string, globals []bool) []*Hook {
+	var hooks []*Hook
+	if c.makross != nil {
+		hooks = c.makross.hooks.get(key)
+	}
+	if len(globals) > 0 && globals[0] {
+		return hooks
 	}
+	return mergeHooks(hooks, c.hooks.get(key))
+}
 
-	if value, okay := m.FiltersMap.Load(key); okay {
-		if b, okay := value.([]byte); okay {
-			return b
+// DoFilterHook runs the hooks of the given key on the bytes returned by function and returns the result.
+// The hooks added to the Makross run together with the ones added to the context, in order of priority,
+// or alone if global is true.
+func (c *Context) DoFilterHook(key string, function func() []byte, globals ...bool) []byte {
+	var b []byte
+	if function != nil {
+		b = function()
+	}
+	b = runHooks(c.contextHooks(key, globals), c, nil, b)
+	if len(globals) > 0 && globals[0] && c.makross != nil {
+		if c.makross.FiltersMap != nil {
+			c.makross.FiltersMap.Store(key, b)
 		}
+	} else if c.FiltersMap != nil {
+		c.FiltersMap.Store(key, b)
 	}
-	return nil
+	return b
 }
 
-// DoFilterHook (c *Context) 执行过滤钩子
-func (c *Context) DoFilterHook(key string, function func() []byte, globals ...bool) []byte {
-	var global = false
-	if len(globals) > 0 {
-		global = globals[0]
+// DoActionHook runs the hooks of the given key, see DoFilterHook.
+func (c *Context) DoActionHook(key string, globals ...bool) {
+	runHooks(c.contextHooks(key, globals), c, nil, nil)
+}
+
+// DoEventHook runs the hooks of the given event added to the Makross and to the context with the given error.
+func (c *Context) DoEventHook(event string, err error) {
+	runHooks(c.contextHooks(event, nil), c, err, nil)
+}
+
+// HasFilterHook returns whether the given key has hooks added to the context, or to the Makross if global is true.
+func (c *Context) HasFilterHook(key string, globals ...bool) bool {
+	if len(globals) > 0 && globals[0] {
+		return c.makross.HasFilterHook(key)
 	}
+	return c.hooks.has(key)
+}
 
-	var filterBytes []byte
+// HasActionHook returns whether the given key has hooks added to the context, or to the Makross if global is true.
+func (c *Context) HasActionHook(key string, globals ...bool) bool {
+	return c.HasFilterHook(key, globals...)
+}
 
-	if !c.HasQueuesMap(key) {
-		c.AddFilterHook(key, func(b []byte) []byte {
-			if function == nil {
-				return b
-			}
-			return function()
-		})
-		return c.DoFilterHook(key, function, globals...)
+// RemoveFilterHook removes the hooks of the given key added to the context, or to the Makross if global is true.
+func (c *Context) RemoveFilterHook(key string, globals ...bool) {
+	if len(globals) > 0 && globals[0] {
+		c.makross.RemoveFilterHook(key)
+		return
 	}
+	c.hooks.removeKey(key)
+}
 
-	if !c.HasFilterHook(key, global) {
-		if global {
-			if c.makross.FiltersMap == nil {
-				c.makross.FiltersMap = new(sync.Map) //make(map[string][]byte)
-			}
-		} else {
-			if c.FiltersMap == nil {
-				c.FiltersMap = new(sync.Map) // make(map[string][]byte)
-			}
-		}
+// RemoveActionHook removes the hooks of the given key added to the context, or to the Makross if global is true.
+func (c *Context) RemoveActionHook(key string, globals ...bool) {
+	c.RemoveFilterHook(key, globals...)
+}
+
+// RemoveActionsHook removes all hooks added to the context, or to the Makross if global is true.
+func (c *Context) RemoveActionsHook(globals ...bool) {
+	if len(globals) > 0 && globals[0] {
+		c.makross.RemoveActionsHook()
+		return
 	}
+	c.hooks.removeAll()
+}
 
-	if c.HasActionHook(key) {
-		if queue, okay := c.makross.QueuesMap.Load(key); okay {
-			pq, okay := queue.(*prior.PriorityQueue)
-			if !okay {
-				return nil
-			}
-			for pq.Length() > 0 {
-				if node := pq.Pop(); node != nil {
-					if value := node.GetValue(); value != nil {
-						if callback, okay = value.(func([]byte) []byte); !okay {
-							return nil
-						}
-					} else {
-						continue
-					}
-				} else {
-					continue
-				}
-
-				if global {
-					if function != nil { //for Global Filter
-						if value, okay := c.makross.FiltersMap.Load(key); okay {
-							if b, okay := value.([]byte); okay {
-								c.makross.FiltersMap.Store(key, callback(b))
-							}
-						} else {
-							c.makross.FiltersMap.Store(key, callback(function()))
-						}
-					} else { //for Global Action
-						c.makross.FiltersMap.Store(key, callback(nil))
-					}
-					if value, okay := c.makross.FiltersMap.Load(key); okay {
-						if b, okay := value.([]byte); okay {
-							filterBytes = b
-						}
-					}
-				} else {
-					if function != nil { //for Filter
-						if value, okay := c.FiltersMap.Load(key); okay {
-							if b, okay := value.([]byte); okay {
-								c.FiltersMap.Store(key, callback(b))
-							}
-						} else {
-							c.FiltersMap.Store(key, callback(function()))
-						}
-					} else { //for Action
-						c.FiltersMap.Store(key, callback(nil))
-					}
-					if value, okay := c.FiltersMap.Load(key); okay {
-						if b, okay := value.([]byte); okay {
-							filterBytes = b
-						}
-					}
-				}
-			}
-		}
+// NewPriorityQueue returns an empty priority queue.
+//
+// Deprecated: hooks are no longer stored in priority queues; use AddFilter and AddAction.
+func (m *Makross) NewPriorityQueue() *prior.PriorityQueue {
+	return prior.NewPriorityQueue()
+}
+
+// NewNode returns a node of a priority queue.
+//
+// Deprecated: hooks are no longer stored in priority queues; use AddFilter and AddAction.
+func (m *Makross) NewNode(key interface{}, v interface{}, priority int) *prior.Node {
+	return prior.NewNode(key, v, priority)
+}
+
+// NewPriorityQueue returns an empty priority queue.
+//
+// Deprecated: hooks are no longer stored in priority queues; use AddFilter and AddAction.
+func (c *Context) NewPriorityQueue() *prior.PriorityQueue {
+	return prior.NewPriorityQueue()
+}
+
+// NewNode returns a node of a priority queue.
+//
+// Deprecated: hooks are no longer stored in priority queues; use AddFilter and AddAction.
+func (c *Context) NewNode(key interface{}, v interface{}, priority int) *prior.Node {
+	return prior.NewNode(key, v, priority)
+}
+
+// SetPriorityQueueWith stores an empty priority queue for the given key in QueuesMap.
+//
+// Deprecated: QueuesMap is not used by the hooks any more.
+func (m *Makross) SetPriorityQueueWith(key interface{}) *sync.Map {
+	if m.QueuesMap == nil {
+		m.QueuesMap = new(sync.Map)
 	}
+	m.QueuesMap.Store(key, m.NewPriorityQueue())
+	return m.QueuesMap
+}
+
+// SetPriorityQueueWith stores an empty priority queue for the given key in the QueuesMap of the makross.
+//
+// Deprecated: QueuesMap is not used by the hooks any more.
+func (c *Context) SetPriorityQueueWith(key interface{}) *sync.Map {
+	return c.makross.SetPriorityQueueWith(key)
+}
 
-	return filterBytes
+// HasQueuesMap returns whether the given key has hooks.
+//
+// Deprecated: use HasFilterHook or HasActionHook.
+func (m *Makross) HasQueuesMap(key string) bool {
+	return m.hooks.has(key)
+}
 
+// HasQueuesMap returns whether the given key has hooks added to the makross.
+//
+// Deprecated: use HasFilterHook or HasActionHook.
+func (c *Context) HasQueuesMap(key string) bool {
+	return c.makross.HasQueuesMap(key)
 }
@@ -0,0 +1,214 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterHooks(t *testing.T) {
+	m := New()
+	suffix := func(s string) func([]byte) []byte {
+		return func(b []byte) []byte {
+			return append(b, s...)
+		}
+	}
+	source := func() []byte { return []byte("x") }
+
+	assert.Equal(t, "x", string(m.DoFilterHook("title", source)))
+	assert.False(t, m.HasFilterHook("title"))
+
+	m.AddFilterHook("title", suffix("c"), 10)
+	a := m.AddFilter("title", suffix("a"))
+	m.AddFilterHook("title", suffix("b"))
+	m.AddFilterHook("title", suffix("0"), -1)
+	var actions int
+	m.AddActionHook("title", func() { actions++ }, 5)
+
+	// hooks persist and run in the same order every time
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "x0abc", string(m.DoFilterHook("title", source)))
+	}
+	assert.Equal(t, 3, actions)
+	m.DoActionHook("title")
+	assert.Equal(t, 4, actions)
+
+	assert.Equal(t, "title", a.Key())
+	assert.Equal(t, DefaultPriority, a.Priority())
+	assert.True(t, a.Remove())
+	assert.False(t, a.Remove())
+	assert.Equal(t, "x0bc", string(m.DoFilterHook("title", source)))
+
+	// a hook removing another one while running
+	var d *Hook
+	m.AddFilterHook("title", func(b []byte) []byte {
+		d.Remove()
+		return b
+	}, 20)
+	d = m.AddFilter("title", suffix("d"), 30)
+	assert.Equal(t, "x0bc", string(m.DoFilterHook("title", source)))
+
+	m.RemoveFilterHook("title")
+	assert.False(t, m.HasFilterHook("title"))
+	assert.Equal(t, "x", string(m.DoFilterHook("title", source)))
+
+	m.AddActionHook("a", func() {})
+	m.AddActionHook("b", func() {})
+	m.RemoveActionsHook()
+	assert.False(t, m.HasActionHook("a"))
+	assert.False(t, m.HasActionHook("b"))
+}
+
+func TestContextHooks(t *testing.T) {
+	m := New()
+	m.AddFilterHook("page", func(b []byte) []byte { return append(b, 'g') }, 1)
+	m.Get("/<id>", func(c *Context) error {
+		if c.Param("id").String() == "1" {
+			c.AddFilterHook("page", func(b []byte) []byte { return append(b, 'c') }, 1)
+			c.AddFilterHook("page", func(b []byte) []byte { return append(b, '0') })
+		}
+		assert.False(t, c.HasFilterHook("missing"))
+		assert.True(t, c.HasFilterHook("page", true))
+		global := c.DoFilterHook("page", nil, true)
+		return c.String(string(c.DoFilterHook("page", nil)) + "|" + string(global))
+	})
+
+	serve := func(path string) string {
+		res := httptest.NewRecorder()
+		m.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+		return res.Body.String()
+	}
+	assert.Equal(t, "0gc|g", serve("/1"))
+	// the hooks of the previous request are gone
+	assert.Equal(t, "g|g", serve("/2"))
+	assert.Equal(t, "0gc|g", serve("/1"))
+
+	c := m.NewContext(nil, nil)
+	h := c.AddAction("x", func() {})
+	assert.True(t, c.HasActionHook("x"))
+	assert.False(t, m.HasActionHook("x"))
+	assert.True(t, h.Remove())
+	assert.False(t, c.HasActionHook("x"))
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	m := New()
+	var events []string
+	record := func(c *Context, err error) {
+		switch {
+		case err != nil:
+			events = append(events, "error:"+err.Error())
+		case c == nil:
+			events = append(events, "nil")
+		case c.Route() != nil:
+			events = append(events, "route:"+c.Route().Path())
+		default:
+			events = append(events, "start")
+		}
+	}
+	m.AddEventHook(HookRequestStart, record)
+	m.AddEventHook(HookRouteMatched, record)
+	m.AddEventHook(HookError, record)
+	m.AddEventHook(HookShutdown, record)
+	m.AddEventHook(HookBeforeResponse, func(c *Context, err error) {
+		c.Response.Header().Set("X-Hooked", "global")
+	})
+	m.Use(func(c *Context) error {
+		c.AddEventHook(HookBeforeResponse, func(c *Context, err error) {
+			events = append(events, "before:"+strconv.Itoa(c.Response.Status))
+		})
+		return c.Next()
+	})
+	m.Get("/ok", func(c *Context) error {
+		return c.String("ok")
+	})
+	m.Get("/fail", func(c *Context) error {
+		return NewHTTPError(http.StatusTeapot, "teapot")
+	})
+
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/ok", nil))
+	assert.Equal(t, []string{"start", "route:/ok", "before:200"}, events)
+	assert.Equal(t, "global", res.Header().Get("X-Hooked"))
+
+	events = nil
+	res = httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/fail", nil))
+	assert.Equal(t, []string{"start", "route:/fail", "error:teapot", "before:418"}, events)
+
+	events = nil
+	res = httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/missing", nil))
+	assert.Equal(t, []string{"start", "error:Not Found", "before:404"}, events)
+
+	events = nil
+	var shutdown bool
+	m.AddActionHook(HookShutdown, func() { shutdown = true })
+	assert.Nil(t, m.Shutdown())
+	assert.Equal(t, []string{"nil"}, events)
+	assert.True(t, shutdown)
+}
+
+func TestHooksConcurrency(t *testing.T) {
+	m := New()
+	m.Get("/", func(c *Context) error {
+		c.AddFilterHook("body", func(b []byte) []byte { return append(b, 'c') })
+		return c.String(string(c.DoFilterHook("body", func() []byte { return []byte("x") })))
+	})
+	m.AddFilterHook("body", func(b []byte) []byte { return b }, -1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			h := m.AddFilter("other", func(b []byte) []byte { return b })
+			m.DoFilterHook("other", nil)
+			h.Remove()
+		}()
+		go func() {
+			defer wg.Done()
+			res := httptest.NewRecorder()
+			m.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, "xc", res.Body.String())
+		}()
+	}
+	wg.Wait()
+	assert.False(t, m.HasFilterHook("other"))
+}
+
+func TestDeprecatedHooks(t *testing.T) {
+	m := New()
+	m.AddFilterHook("title", func(b []byte) []byte { return append(b, 'a') })
+	m.AddActionHook("done", func() {})
+	assert.True(t, m.HasQueuesMap("title"))
+	assert.True(t, m.HasQueuesMap("done"))
+	assert.False(t, m.HasQueuesMap("missing"))
+
+	assert.Equal(t, "xa", string(m.DoFilterHook("title", func() []byte { return []byte("x") })))
+	value, ok := m.FiltersMap.Load("title")
+	assert.True(t, ok)
+	assert.Equal(t, "xa", string(value.([]byte)))
+
+	m.SetPriorityQueueWith("queue")
+	value, ok = m.QueuesMap.Load("queue")
+	assert.True(t, ok)
+	assert.Equal(t, 0, value.(interface{ Length() int }).Length())
+	assert.Equal(t, "v", m.NewNode("k", "v", 1).GetValue())
+
+	m.Get("/", func(c *Context) error {
+		c.AddFilterHook("title", func(b []byte) []byte { return append(b, 'c') }, 1)
+		b := c.DoFilterHook("title", func() []byte { return []byte("y") })
+		value, _ := c.FiltersMap.Load("title")
+		return c.String(string(b) + "|" + string(value.([]byte)))
+	})
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "yac|yac", res.Body.String())
+}
@@ -0,0 +1,240 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type (
+	// JSONStreamFormat is the format of the response written by Context.JSONStream.
+	JSONStreamFormat int
+
+	// JSONStreamFunc produces the items of a JSON stream by calling yield for each of them.
+	// yield returns ErrStreamClosed once the client has disconnected, and any error it returns
+	// should be returned by the function, which then stops producing items.
+	JSONStreamFunc func(yield func(item interface{}) error) error
+
+	jsonStream struct {
+		c       *Context
+		format  JSONStreamFormat
+		status  int
+		mu      sync.Mutex
+		w       *bufio.Writer
+		started bool
+		closed  bool
+		count   int
+	}
+)
+
+const (
+	// JSONArray writes the items as the elements of a single JSON array.
+	JSONArray JSONStreamFormat = iota
+	// NDJSON writes newline delimited JSON, one item per line.
+	NDJSON
+)
+
+// JSONStreamFlushInterval is the interval at which the items written by Context.JSONStream are flushed
+// to the client. If it is not positive, every item is flushed as soon as it is written.
+var JSONStreamFlushInterval = time.Second
+
+// JSONStream writes the items produced by source as they come, without holding them all in memory.
+// The source is either a channel, which is read until it is closed, or a JSONStreamFunc.
+// Items are encoded with json.Marshal and written as a JSON array or as newline delimited JSON,
+// depending on format. An item that is an error is treated as if the source had returned it.
+//
+// The stream stops when the client disconnects, in which case ErrStreamClosed is returned.
+// Producers writing to a channel should watch c.Request.Context() as well so that they are not blocked forever.
+//
+// If the source fails before the first item, nothing is written and its error is returned so that it is
+// handled like any other error. Once the response has been started, the error is reported in the
+// X-Stream-Error trailer, NDJSON streams end with an {"error": message} line, and JSON arrays are left
+// unterminated so that clients cannot mistake the partial result for a complete one.
+//
+//	rows := make(chan Row)
+//	go exportRows(c.Request.Context(), rows)
+//	return c.JSONStream(rows, makross.NDJSON)
+func (c *Context) JSONStream(source interface{}, format JSONStreamFormat, status ...int) error {
+	var code int
+	if len(status) > 0 {
+		code = status[0]
+	} else {
+		code = StatusOK
+	}
+
+	var produce JSONStreamFunc
+	switch src := source.(type) {
+	case JSONStreamFunc:
+		produce = src
+	case func(func(interface{}) error) error:
+		produce = src
+	default:
+		v := reflect.ValueOf(source)
+		if v.Kind() != reflect.Chan || v.Type().ChanDir()&reflect.RecvDir == 0 {
+			return ErrUnsupportedStreamSource
+		}
+		produce = receiveJSONStream(v, c.Request.Context().Done())
+	}
+
+	s := &jsonStream{c: c, format: format, status: code}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.watch(stop)
+	}()
+	err := produce(s.write)
+	close(stop)
+	wg.Wait()
+	c.Abort()
+	return s.finish(err)
+}
+
+// receiveJSONStream returns a JSONStreamFunc yielding the values received from the given channel.
+func receiveJSONStream(ch reflect.Value, done <-chan struct{}) JSONStreamFunc {
+	return func(yield func(interface{}) error) error {
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: ch},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)},
+		}
+		for {
+			chosen, item, ok := reflect.Select(cases)
+			if chosen == 1 {
+				return ErrStreamClosed
+			}
+			if !ok {
+				return nil
+			}
+			if err := yield(item.Interface()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *jsonStream) write(item interface{}) error {
+	if err, ok := item.(error); ok {
+		return err
+	}
+	b, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStreamClosed
+	}
+	s.start()
+	if s.format == JSONArray && s.count > 0 {
+		b = append([]byte{','}, b...)
+	} else if s.format == NDJSON {
+		b = append(b, '\n')
+	}
+	if _, err := s.w.Write(b); err != nil {
+		s.closed = true
+		return ErrStreamClosed
+	}
+	s.count++
+	if JSONStreamFlushInterval <= 0 {
+		s.flush()
+	}
+	return nil
+}
+
+// start sends the response header and opens the JSON array.
+func (s *jsonStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	s.c.streamed = true
+	header := s.c.Response.Header()
+	if s.format == NDJSON {
+		header.Set(HeaderContentType, MIMEApplicationNDJSON)
+	} else {
+		header.Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+	}
+	header.Set(HeaderTrailer, HeaderXStreamError)
+	s.c.Response.WriteHeader(s.status)
+	s.w = bufio.NewWriterSize(s.c.Response, 32<<10)
+	if s.format == JSONArray {
+		s.w.WriteByte('[')
+	}
+}
+
+func (s *jsonStream) flush() {
+	if err := s.w.Flush(); err != nil {
+		s.closed = true
+		return
+	}
+	if _, ok := s.c.Response.Writer.(http.Flusher); ok {
+		s.c.Response.Flush()
+	}
+}
+
+// finish completes the stream after the source has returned the given error.
+func (s *jsonStream) finish(err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || err == ErrStreamClosed {
+		return ErrStreamClosed
+	}
+	if err != nil {
+		if !s.started {
+			return err
+		}
+		msg := err.Error()
+		if httpError, ok := err.(*HTTPError); ok {
+			msg = httpError.Message
+		}
+		s.c.Response.Header().Set(HeaderXStreamError, msg)
+		if s.format == NDJSON {
+			b, _ := json.Marshal(map[string]string{"error": msg})
+			s.w.Write(append(b, '\n'))
+		}
+		s.flush()
+		return err
+	}
+	s.start()
+	if s.format == JSONArray {
+		s.w.WriteByte(']')
+	}
+	s.flush()
+	return nil
+}
+
+// watch flushes the stream periodically and closes it when the client disconnects.
+func (s *jsonStream) watch(stop <-chan struct{}) {
+	var tick <-chan time.Time
+	if JSONStreamFlushInterval > 0 {
+		ticker := time.NewTicker(JSONStreamFlushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	disconnected := s.c.Request.Context().Done()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-disconnected:
+			s.mu.Lock()
+			s.closed = true
+			s.mu.Unlock()
+			return
+		case <-tick:
+			s.mu.Lock()
+			if s.started && !s.closed {
+				s.flush()
+			}
+			s.mu.Unlock()
+		}
+	}
+}
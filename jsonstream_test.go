@@ -0,0 +1,220 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	flushes int32
+}
+
+func (w *flushCounter) Flush() {
+	atomic.AddInt32(&w.flushes, 1)
+	w.ResponseRecorder.Flush()
+}
+
+func TestJSONStream(t *testing.T) {
+	type row struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	serve := func(h Handler) *httptest.ResponseRecorder {
+		m := New()
+		m.Get("/", h)
+		res := httptest.NewRecorder()
+		m.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+		return res
+	}
+
+	// channel source, NDJSON
+	res := serve(func(c *Context) error {
+		rows := make(chan row)
+		go func() {
+			defer close(rows)
+			rows <- row{1, "a"}
+			rows <- row{2, "b"}
+		}()
+		return c.JSONStream(rows, NDJSON)
+	})
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, MIMEApplicationNDJSON, res.Header().Get(HeaderContentType))
+	assert.Equal(t, "{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"b\"}\n", res.Body.String())
+
+	// iterator source, JSON array
+	res = serve(func(c *Context) error {
+		return c.JSONStream(func(yield func(interface{}) error) error {
+			for i := 1; i <= 3; i++ {
+				if err := yield(i); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, JSONArray, http.StatusCreated)
+	})
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, MIMEApplicationJSONCharsetUTF8, res.Header().Get(HeaderContentType))
+	assert.Equal(t, "[1,2,3]", res.Body.String())
+
+	// empty sources
+	res = serve(func(c *Context) error {
+		rows := make(chan int)
+		close(rows)
+		return c.JSONStream(rows, JSONArray)
+	})
+	assert.Equal(t, "[]", res.Body.String())
+	res = serve(func(c *Context) error {
+		return c.JSONStream(JSONStreamFunc(func(yield func(interface{}) error) error { return nil }), NDJSON)
+	})
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "", res.Body.String())
+
+	// unsupported source
+	res = serve(func(c *Context) error {
+		return c.JSONStream([]int{1, 2}, JSONArray)
+	})
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, ErrUnsupportedStreamSource.Error(), res.Body.String())
+}
+
+func TestJSONStreamErrors(t *testing.T) {
+	failAfter := func(n int, err error) JSONStreamFunc {
+		return func(yield func(interface{}) error) error {
+			for i := 1; i <= n; i++ {
+				if err := yield(i); err != nil {
+					return err
+				}
+			}
+			return err
+		}
+	}
+	serve := func(source interface{}, format JSONStreamFormat) (*httptest.ResponseRecorder, error) {
+		var streamErr error
+		m := New()
+		m.Get("/", func(c *Context) error {
+			streamErr = c.JSONStream(source, format)
+			return streamErr
+		})
+		res := httptest.NewRecorder()
+		m.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+		return res, streamErr
+	}
+
+	// an error before the first item is handled as usual
+	res, err := serve(failAfter(0, NewHTTPError(http.StatusServiceUnavailable, "database down")), NDJSON)
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, "database down", res.Body.String())
+	assert.NotNil(t, err)
+
+	// an error midway ends NDJSON with an error line and sets the trailer
+	res, err = serve(failAfter(2, errors.New("read failed")), NDJSON)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "1\n2\n{\"error\":\"read failed\"}\n", res.Body.String())
+	assert.Equal(t, "read failed", res.Result().Trailer.Get(HeaderXStreamError))
+	assert.EqualError(t, err, "read failed")
+
+	// a JSON array is left unterminated
+	res, err = serve(failAfter(2, errors.New("read failed")), JSONArray)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "[1,2", res.Body.String())
+	assert.Equal(t, "read failed", res.Result().Trailer.Get(HeaderXStreamError))
+
+	// errors sent on a channel and items that cannot be encoded
+	items := make(chan interface{}, 3)
+	items <- "a"
+	items <- errors.New("bad row")
+	close(items)
+	res, err = serve(items, NDJSON)
+	assert.Equal(t, "\"a\"\n{\"error\":\"bad row\"}\n", res.Body.String())
+	assert.EqualError(t, err, "bad row")
+	res, err = serve(func(yield func(interface{}) error) error {
+		return yield(func() {})
+	}, JSONArray)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.NotNil(t, err)
+
+	// only the errors of a started stream are kept out of the response by the error handler
+	m := New()
+	m.Get("/", func(c *Context) error {
+		c.Response.Write([]byte("partial "))
+		return errors.New("failed")
+	})
+	res = httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "partial failed", res.Body.String())
+}
+
+func TestJSONStreamDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := New()
+	var streamErr, yieldErr error
+	m.Get("/chan", func(c *Context) error {
+		rows := make(chan int)
+		go func() {
+			rows <- 1
+			cancel()
+		}()
+		streamErr = c.JSONStream(rows, NDJSON)
+		return nil
+	})
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/chan", nil).WithContext(ctx))
+	assert.Equal(t, ErrStreamClosed, streamErr)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	m.Get("/func", func(c *Context) error {
+		streamErr = c.JSONStream(func(yield func(interface{}) error) error {
+			for i := 0; ; i++ {
+				if i == 1 {
+					cancel()
+				}
+				if yieldErr = yield(i); yieldErr != nil {
+					return yieldErr
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}, JSONArray)
+		return nil
+	})
+	res = httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/func", nil).WithContext(ctx))
+	assert.Equal(t, ErrStreamClosed, yieldErr)
+	assert.Equal(t, ErrStreamClosed, streamErr)
+}
+
+func TestJSONStreamFlush(t *testing.T) {
+	defer func(interval time.Duration) {
+		JSONStreamFlushInterval = interval
+	}(JSONStreamFlushInterval)
+	JSONStreamFlushInterval = 10 * time.Millisecond
+
+	m := New()
+	var flushed bool
+	res := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	m.Get("/", func(c *Context) error {
+		return c.JSONStream(func(yield func(interface{}) error) error {
+			if err := yield(1); err != nil {
+				return err
+			}
+			for i := 0; i < 100 && !flushed; i++ {
+				flushed = atomic.LoadInt32(&res.flushes) > 0
+				time.Sleep(5 * time.Millisecond)
+			}
+			return yield(2)
+		}, NDJSON)
+	})
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	assert.True(t, flushed)
+	assert.Equal(t, "1\n2\n", res.Body.String())
+}
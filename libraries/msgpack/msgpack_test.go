@@ -0,0 +1,129 @@
+package msgpack
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type base struct {
+	ID int64 `msgpack:"id"`
+}
+
+type user struct {
+	base
+	Name    string            `msgpack:"name"`
+	Email   string            `msgpack:"email,omitempty"`
+	Age     uint8             `json:"age"`
+	Tags    []string          `msgpack:"tags"`
+	Attrs   map[string]string `msgpack:"attrs"`
+	Avatar  []byte            `msgpack:"avatar"`
+	Score   float64           `msgpack:"score"`
+	Admin   *bool             `msgpack:"admin"`
+	Created time.Time         `msgpack:"created"`
+	Secret  string            `msgpack:"-"`
+}
+
+func TestMarshalFormats(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{false, []byte{0xc2}},
+		{1, []byte{0x01}},
+		{-1, []byte{0xff}},
+		{-33, []byte{0xd0, 0xdf}},
+		{200, []byte{0xcc, 0xc8}},
+		{uint16(1000), []byte{0xcd, 0x03, 0xe8}},
+		{-1000, []byte{0xd1, 0xfc, 0x18}},
+		{"abc", []byte{0xa3, 'a', 'b', 'c'}},
+		{[]byte{1, 2}, []byte{0xc4, 0x02, 0x01, 0x02}},
+		{[]int{1, 2}, []byte{0x92, 0x01, 0x02}},
+		{map[string]int{"b": 2, "a": 1}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{time.Unix(1, 0), []byte{0xd6, 0xff, 0, 0, 0, 1}},
+	}
+	for _, test := range tests {
+		b, err := Marshal(test.value)
+		assert.Nil(t, err, "%v", test.value)
+		assert.Equal(t, test.expected, b, "%v", test.value)
+	}
+
+	_, err := Marshal(make(chan int))
+	assert.NotNil(t, err)
+}
+
+func TestRoundTrip(t *testing.T) {
+	admin := true
+	in := user{
+		base:    base{ID: 42},
+		Name:    "makross",
+		Age:     18,
+		Tags:    []string{"a", "b"},
+		Attrs:   map[string]string{"k": "v"},
+		Avatar:  []byte{0, 1, 2},
+		Score:   math.Pi,
+		Admin:   &admin,
+		Created: time.Unix(1500000000, 123).UTC(),
+		Secret:  "hidden",
+	}
+	b, err := Marshal(&in)
+	if assert.Nil(t, err) {
+		var out user
+		assert.Nil(t, Unmarshal(b, &out))
+		assert.Equal(t, int64(42), out.ID)
+		assert.Equal(t, "makross", out.Name)
+		assert.Equal(t, uint8(18), out.Age)
+		assert.Equal(t, in.Tags, out.Tags)
+		assert.Equal(t, in.Attrs, out.Attrs)
+		assert.Equal(t, in.Avatar, out.Avatar)
+		assert.Equal(t, math.Pi, out.Score)
+		assert.True(t, *out.Admin)
+		assert.True(t, in.Created.Equal(out.Created))
+		assert.Equal(t, "", out.Secret)
+
+		var m map[string]interface{}
+		assert.Nil(t, Unmarshal(b, &m))
+		assert.Equal(t, int64(42), m["id"])
+		_, ok := m["email"]
+		assert.False(t, ok)
+		_, ok = m["Secret"]
+		assert.False(t, ok)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var i int8
+	b, _ := Marshal(1000)
+	assert.NotNil(t, Unmarshal(b, &i))
+
+	var u uint
+	b, _ = Marshal(-1)
+	assert.NotNil(t, Unmarshal(b, &u))
+
+	var s string
+	assert.NotNil(t, Unmarshal(b, &s))
+	assert.NotNil(t, Unmarshal(b, s))
+
+	assert.Equal(t, ErrShortBuffer, Unmarshal([]byte{0xa3, 'a'}, &s))
+	assert.Equal(t, ErrShortBuffer, Unmarshal([]byte{0xdd, 0xff, 0xff, 0xff, 0xff}, &s))
+	assert.Equal(t, ErrTrailingData, Unmarshal([]byte{0x01, 0x02}, &u))
+	assert.NotNil(t, Unmarshal([]byte{0xc1}, &u))
+}
+
+func TestUnmarshalMaxDepth(t *testing.T) {
+	var v interface{}
+	// 0x91 is an array of one element, nested far deeper than MaxDepth
+	data := bytes.Repeat([]byte{0x91}, 20<<20)
+	assert.Equal(t, ErrMaxDepth, Unmarshal(data, &v))
+	data = bytes.Repeat([]byte{0x81, 0xa1, 'k'}, MaxDepth+1)
+	assert.Equal(t, ErrMaxDepth, Unmarshal(append(data, 0xc0), &v))
+
+	data = append(bytes.Repeat([]byte{0x91}, MaxDepth), 0xc0)
+	assert.Nil(t, Unmarshal(data, &v))
+}
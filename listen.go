@@ -17,7 +17,7 @@ func (m *Makross) Listen(args ...interface{}) {
 	} else {
 		runtime.GOMAXPROCS(runtime.NumCPU() * 4)
 	}
-	m.DoActionHook("MakrossListen")
+	m.DoActionHook(HookListen)
 	m.Server.Addr = addr
 
 	log.Fatal(m.Server.ListenAndServe())
@@ -30,7 +30,7 @@ func (m *Makross) ListenTLS(certFile, keyFile string, args ...interface{}) {
 	} else {
 		runtime.GOMAXPROCS(runtime.NumCPU() * 4)
 	}
-	m.DoActionHook("MakrossListenTLS")
+	m.DoActionHook(HookListenTLS)
 	m.Server.Addr = addr
 
 	log.Fatal(m.Server.ListenAndServeTLS(certFile, keyFile))
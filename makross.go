@@ -4,12 +4,15 @@ package makross
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"path"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/insionng/makross/libraries/ini.v1"
@@ -19,22 +22,38 @@ type (
 	// Handler is the function for handling HTTP requests.
 	Handler func(*Context) error
 
+	// ErrorHandler is the function for handling the errors returned by the handlers.
+	ErrorHandler func(*Context, error)
+
 	// Makross manages routes and dispatches HTTP requests to the handlers of the matching routes.
 	Makross struct {
 		RouteGroup
+		*routeTable              // the routing table being built
+		table       atomic.Value // the published *routeTable used to dispatch requests
+		mu          sync.Mutex   // serializes the changes of the routing table
+		updating    atomic.Value // the *RouteUpdate in progress, if any
 		pool        sync.Pool
-		routes      []*Route
-		namedRoutes map[string]*Route
-		stores      map[string]routeStore
 		data        map[string]interface{} // data items managed by Key , Value
-
-		QueuesMap  *sync.Map //map[string]*prior.PriorityQueue
+		parent      *Makross               // the makross this one is mounted to, nil if not mounted
+		mountPrefix string                 // the URL template of the prefix this makross is mounted at
+		mountHost   string                 // the host pattern of the group this makross is mounted to, if any
+		mounts      []*Makross             // the makross instances mounted to this one
+		mountChain  []Handler              // the handlers the parent runs before delegating to this makross
+		hooks       hookSet                // the hooks added by AddFilter, AddAction and AddEventHook
+
+		// Deprecated: QueuesMap is not used by the hooks any more, and only filled by SetPriorityQueueWith.
+		QueuesMap *sync.Map //map[string]*prior.PriorityQueue
+		// Deprecated: FiltersMap holds the last result of DoFilterHook for each key; use the value it returns.
 		FiltersMap *sync.Map //map[string][]byte // Global Filters
 
-		maxParams        int
 		notFound         []Handler
 		notFoundHandlers []Handler
+		optionsHandlers  []Handler // handlers answering OPTIONS requests automatically
+		autoMethods      bool      // whether HEAD and OPTIONS requests are answered automatically
+		problemDetails   bool      // whether errors are rendered as RFC 7807 problem details
+		errorHandler     ErrorHandler
 		binder           Binder
+		validator        Validator
 		renderer         Renderer
 		Server           *http.Server
 	}
@@ -42,7 +61,7 @@ type (
 	// routeStore stores route paths and the corresponding handlers.
 	routeStore interface {
 		Add(key string, data interface{}) int
-		Get(key string, pvalues []string) (data interface{}, pnames []string)
+		Get(key string, pvalues []string, ptyped []interface{}) (data interface{}, pnames []string)
 		String() string
 	}
 
@@ -105,6 +124,10 @@ const (
 	MIMETextPlainCharsetUTF8             = MIMETextPlain + "; " + charsetUTF8
 	MIMEMultipartForm                    = "multipart/form-data"
 	MIMEOctetStream                      = "application/octet-stream"
+	MIMETextEventStream                  = "text/event-stream"
+	MIMEApplicationProblemJSON           = "application/problem+json"
+	MIMEApplicationProblemXML            = "application/problem+xml"
+	MIMEApplicationNDJSON                = "application/x-ndjson"
 )
 
 const (
@@ -117,14 +140,18 @@ const (
 	HeaderAcceptEncoding      = "Accept-Encoding"
 	HeaderAllow               = "Allow"
 	HeaderAuthorization       = "Authorization"
+	HeaderCacheControl        = "Cache-Control"
 	HeaderContentDisposition  = "Content-Disposition"
 	HeaderContentEncoding     = "Content-Encoding"
 	HeaderContentLength       = "Content-Length"
 	HeaderContentType         = "Content-Type"
 	HeaderCookie              = "Cookie"
 	HeaderSetCookie           = "Set-Cookie"
+	HeaderETag                = "ETag"
 	HeaderIfModifiedSince     = "If-Modified-Since"
+	HeaderIfNoneMatch         = "If-None-Match"
 	HeaderLastModified        = "Last-Modified"
+	HeaderLastEventID         = "Last-Event-ID"
 	HeaderLocation            = "Location"
 	HeaderUpgrade             = "Upgrade"
 	HeaderVary                = "Vary"
@@ -138,6 +165,8 @@ const (
 	HeaderXRealIP             = "X-Real-IP"
 	HeaderXRequestID          = "X-Request-ID"
 	HeaderServer              = "Server"
+	HeaderTrailer             = "Trailer"
+	HeaderXStreamError        = "X-Stream-Error"
 	HeaderOrigin              = "Origin"
 
 	// Access control
@@ -304,16 +333,18 @@ func StatusText(code int) string {
 // New creates a new Makross object.
 func New() (m *Makross) {
 	m = &Makross{
-		Server:      new(http.Server),
-		namedRoutes: make(map[string]*Route),
-		stores:      make(map[string]routeStore),
-		QueuesMap:   new(sync.Map),
-		FiltersMap:  new(sync.Map),
+		Server:     new(http.Server),
+		routeTable: newRouteTable(),
+		QueuesMap:  new(sync.Map),
+		FiltersMap: new(sync.Map),
 	}
+	m.table.Store(m.routeTable)
 	m.Server.Handler = m
 	m.RouteGroup = *newRouteGroup("", m, make([]Handler, 0))
 	m.NotFound(MethodNotAllowedHandler, NotFoundHandler)
+	m.optionsHandlers = combineHandlers(m.handlers, []Handler{optionsHandler})
 	m.SetBinder(&DefaultBinder{})
+	m.SetValidator(&DefaultValidator{})
 	m.pool.New = func() interface{} {
 		return m.NewContext(nil, nil)
 	}
@@ -326,7 +357,8 @@ func (m *Makross) NewContext(r *http.Request, w http.ResponseWriter, handlers ..
 		Request:  r,
 		Response: NewResponse(w, m),
 		makross:  m,
-		pvalues:  make([]string, m.maxParams),
+		pvalues:  make([]string, m.currentTable().maxParams),
+		ptyped:   make([]interface{}, m.currentTable().maxParams),
 		handlers: handlers,
 	}
 	c.Reset(w, r)
@@ -354,13 +386,54 @@ func (m *Makross) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	c := m.AcquireContext()
 	c.Reset(res, req)
 	c.Response.Header().Set("Server", "Makross")
-	c.handlers, c.pnames = m.find(req.Method, req.URL.Path, c.pvalues)
+	t := m.currentTable()
+	if len(c.pvalues) < t.maxParams {
+		// routes with more parameters have been added since the context was created
+		c.pvalues = make([]string, t.maxParams)
+		c.ptyped = make([]interface{}, t.maxParams)
+	}
+	c.DoEventHook(HookRequestStart, nil)
+	if m.hooks.has(HookBeforeResponse) {
+		c.hookBeforeResponse()
+	}
+	m.match(c, t)
+	if c.route != nil {
+		c.DoEventHook(HookRouteMatched, nil)
+	}
 	if err := c.Next(); err != nil {
 		m.HandleError(c, err)
 	}
+	c.Response.commit()
+	c.Response.runAfter()
 	m.ReleaseContext(c)
 }
 
+// match finds the route matching the request of the context and sets the handlers to be executed.
+func (m *Makross) match(c *Context, t *routeTable) {
+	req := c.Request
+	host := requestHost(req)
+	if c.route, c.pnames = t.lookup(req.Method, host, req.URL.Path, c.pvalues, c.ptyped); c.route != nil {
+		c.handlers = c.route.handlers
+		return
+	}
+	if m.autoMethods {
+		switch req.Method {
+		case HEAD:
+			if c.route, c.pnames = t.lookup(GET, host, req.URL.Path, c.pvalues, c.ptyped); c.route != nil {
+				c.handlers = c.route.handlers
+				c.Response.Writer = &headResponseWriter{c.Response.Writer}
+				return
+			}
+		case OPTIONS:
+			if len(t.allowedMethods(host, req.URL.Path)) > 0 {
+				c.handlers = m.optionsHandlers
+				return
+			}
+		}
+	}
+	c.handlers = m.notFoundHandlers
+}
+
 // Shutdown 优雅停止HTTP服务 不超过特定时长
 func (m *Makross) Shutdown(times ...int64) error {
 	var n time.Duration
@@ -371,31 +444,60 @@ func (m *Makross) Shutdown(times ...int64) error {
 	}
 	// shut down gracefully, but wait no longer than n seconds before halting
 	ctx, _ := context.WithTimeout(context.Background(), n*time.Second)
-	m.DoActionHook("MakrossShutdown")
+	m.DoEventHook(HookShutdown, nil, nil)
 	return m.Server.Shutdown(ctx)
 }
 
 // Close 立即关闭HTTP服务
 func (m *Makross) Close() error {
-	m.DoActionHook("MakrossClose")
+	m.DoActionHook(HookClose)
 	return m.Server.Close()
 }
 
 // Route returns the named route.
+// The routes of the mounted makross instances are searched if the makross has no such route.
 // Nil is returned if the named route cannot be found.
 func (m *Makross) Route(name string) *Route {
-	return m.namedRoutes[name]
+	if r := m.currentTable().namedRoutes[name]; r != nil {
+		return r
+	}
+	for _, child := range m.mounts {
+		if r := child.Route(name); r != nil {
+			return r
+		}
+	}
+	return nil
 }
 
 // Routes returns all routes managed by the makross.
 func (m *Makross) Routes() []*Route {
-	return m.routes
+	return m.currentTable().routes
 }
 
 // Use appends the specified handlers to the makross and shares them with all routes.
 func (r *Makross) Use(handlers ...Handler) {
 	r.RouteGroup.Use(handlers...)
 	r.notFoundHandlers = combineHandlers(r.handlers, r.notFound)
+	r.optionsHandlers = combineHandlers(r.handlers, []Handler{optionsHandler})
+}
+
+// SetAutoMethods enables or disables answering HEAD and OPTIONS requests automatically.
+// When enabled, a HEAD request without a matching HEAD route is served by the matching GET route
+// with the response body discarded, and an OPTIONS request without a matching OPTIONS route is
+// answered with an Allow header listing the methods allowed for the requested URL.
+// Routes registered explicitly for HEAD or OPTIONS take precedence, and the handlers registered
+// via Use are executed in both cases.
+func (m *Makross) SetAutoMethods(enabled bool) {
+	m.autoMethods = enabled
+}
+
+// SetProblemDetails enables or disables rendering the errors handled by HandleError as
+// RFC 7807 problem details. When enabled, the errors are written by Context.Problem as
+// application/problem+json or application/problem+xml according to the Accept header of
+// the request, while the clients preferring HTML or plain text, such as browsers, still
+// receive the error message as plain text.
+func (m *Makross) SetProblemDetails(enabled bool) {
+	m.problemDetails = enabled
 }
 
 // SetRenderer registers an HTML template renderer. It's invoked by `Context#Render()`.
@@ -413,6 +515,17 @@ func (m *Makross) Binder() Binder {
 	return m.binder
 }
 
+// SetValidator registers a validator. It's invoked by `Context#Bind()` after the data is bound.
+// Validation is disabled if the validator is nil.
+func (m *Makross) SetValidator(v Validator) {
+	m.validator = v
+}
+
+// Validator returns the validator instance.
+func (m *Makross) Validator() Validator {
+	return m.validator
+}
+
 func (m *Makross) Pull(key string) interface{} {
 	return m.data[key]
 }
@@ -486,65 +599,125 @@ func (m *Makross) NewHTTPError(status int, message ...interface{}) *HTTPError {
 	return NewHTTPError(status, message...)
 }
 
+// SetErrorHandler sets the handler of the errors that are not handled by the handlers of the routes,
+// which is used for the routes whose groups have no error handler set by RouteGroup.SetErrorHandler,
+// and for the requests without a matching route. DefaultErrorHandler is used if the handler is nil.
+func (m *Makross) SetErrorHandler(h ErrorHandler) {
+	m.errorHandler = h
+}
+
 // HandleError is the error handler for handling any unhandled errors.
+// The error is passed to the error handler of the group of the matched route, falling back to the
+// handlers of its parent groups, the handler set by SetErrorHandler and DefaultErrorHandler in turn.
 func (m *Makross) HandleError(c *Context, err interface{}) {
+	e, okay := err.(error)
+	if !okay && err != nil {
+		e = fmt.Errorf("%v", err)
+	}
+	c.DoEventHook(HookError, e)
+	var h ErrorHandler
+	if c.route != nil && c.route.group != nil {
+		h = c.route.group.findErrorHandler()
+	}
+	if h == nil {
+		h = m.errorHandler
+	}
+	if h == nil {
+		h = DefaultErrorHandler
+	}
+	h(c, e)
+}
 
+// DefaultErrorHandler sets an appropriate HTTP status code and writes the error message to the response,
+// or the problem details of the error if they are enabled by Makross.SetProblemDetails.
+// Error handlers may call it to handle the errors they are not interested in.
+func DefaultErrorHandler(c *Context, err error) {
+	if c.streamed {
+		// the error has been reported in the JSON stream, which cannot be replaced by an error response
+		log.Println("[Makross] JSON stream failed:", err)
+		return
+	}
 	status := StatusInternalServerError
 	msg := StatusText(status)
 	if httpError, okay := err.(*HTTPError); okay {
 		status = httpError.Status
 		msg = httpError.Message
-	} else if iError, okay := err.(error); okay {
-		msg = iError.Error()
+	} else if err != nil {
+		msg = err.Error()
 	}
 	if c.Request != nil && c.Request.Method == HEAD {
 		c.NoContent(status)
+	} else if c.makross != nil && c.makross.problemDetails && c.Request != nil {
+		httpError, okay := err.(*HTTPError)
+		if !okay {
+			httpError = NewHTTPError(status, msg)
+		}
+		c.Problem(httpError)
 	} else {
 		c.String(msg, status)
 	}
 }
 
-func (r *Makross) addRoute(route *Route, handlers []Handler) {
-	path := route.group.prefix + route.path
-
-	r.routes = append(r.routes, route)
-
-	store := r.stores[route.method]
-	if store == nil {
-		store = newStore()
-		r.stores[route.method] = store
+// mountTemplate returns the URL template of the path this makross is mounted at.
+func (m *Makross) mountTemplate() string {
+	if m.parent == nil {
+		return ""
 	}
+	return m.parent.mountTemplate() + m.mountPrefix
+}
 
-	// an asterisk at the end matches any number of characters
-	if strings.HasSuffix(path, "*") {
-		path = path[:len(path)-1] + "<:.*>"
+// mountedHost returns the host pattern this makross is mounted at, or an empty string if there is none.
+func (m *Makross) mountedHost() string {
+	for ; m.parent != nil; m = m.parent {
+		if m.mountHost != "" {
+			return m.mountHost
+		}
 	}
+	return ""
+}
 
-	if n := store.Add(path, handlers); n > r.maxParams {
-		r.maxParams = n
-	}
+func (r *Makross) addRoute(route *Route, handlers []Handler) {
+	route.handlers = handlers
+	route.group.withTable(func(t *routeTable, pending bool) {
+		t.routes = append(t.routes, route)
+		if !pending {
+			// the stores of a table being updated are built when the update completes
+			t.insert(route)
+		}
+		t.checkRouteConflicts(route)
+	})
 }
 
-func (m *Makross) find(method, path string, pvalues []string) (handlers []Handler, pnames []string) {
-	var hs interface{}
-	if store := m.stores[method]; store != nil {
-		hs, pnames = store.Get(path, pvalues)
+func (r *Makross) findAllowedMethods(host, path string) map[string]bool {
+	return r.currentTable().allowedMethods(host, path)
+}
+
+// allowHeader returns the value of the Allow HTTP header for the given host and path.
+// An empty string is returned if no route matches them.
+func (r *Makross) allowHeader(host, path string) string {
+	methods := r.findAllowedMethods(host, path)
+	if len(methods) == 0 {
+		return ""
 	}
-	if hs != nil {
-		return hs.([]Handler), pnames
+	methods[OPTIONS] = true
+	if r.autoMethods && methods[GET] {
+		methods[HEAD] = true
 	}
-	return m.notFoundHandlers, pnames
+	ms := make([]string, 0, len(methods))
+	for method := range methods {
+		ms = append(ms, method)
+	}
+	sort.Strings(ms)
+	return strings.Join(ms, ", ")
 }
 
-func (r *Makross) findAllowedMethods(path string) map[string]bool {
-	methods := make(map[string]bool)
-	pvalues := make([]string, r.maxParams)
-	for m, store := range r.stores {
-		if handlers, _ := store.Get(path, pvalues); handlers != nil {
-			methods[m] = true
-		}
+// requestHost returns the lower-cased host of the request without the port.
+func requestHost(req *http.Request) string {
+	host := req.Host
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && strings.IndexByte(host[i:], ']') < 0 {
+		host = host[:i]
 	}
-	return methods
+	return strings.ToLower(host)
 }
 
 // NotFoundHandler returns a 404 HTTP error indicating a request has no matching route.
@@ -556,19 +729,11 @@ func NotFoundHandler(*Context) error {
 // In this case, the handler will respond with an Allow HTTP header listing the allowed HTTP methods.
 // Otherwise, the handler will do nothing and let the next handler (usually a NotFoundHandler) to handle the problem.
 func MethodNotAllowedHandler(c *Context) error {
-	methods := c.Makross().findAllowedMethods(c.Request.URL.Path)
-	if len(methods) == 0 {
+	allow := c.Makross().allowHeader(requestHost(c.Request), c.Request.URL.Path)
+	if allow == "" {
 		return nil
 	}
-	methods["OPTIONS"] = true
-	ms := make([]string, len(methods))
-	i := 0
-	for method := range methods {
-		ms[i] = method
-		i++
-	}
-	sort.Strings(ms)
-	c.Response.Header().Set("Allow", strings.Join(ms, ", "))
+	c.Response.Header().Set("Allow", allow)
 	if c.Request.Method != "OPTIONS" {
 		c.Response.WriteHeader(StatusMethodNotAllowed)
 	}
@@ -576,6 +741,12 @@ func MethodNotAllowedHandler(c *Context) error {
 	return nil
 }
 
+// optionsHandler answers an OPTIONS request with an Allow HTTP header listing the allowed HTTP methods.
+func optionsHandler(c *Context) error {
+	c.Response.Header().Set(HeaderAllow, c.Makross().allowHeader(requestHost(c.Request), c.Request.URL.Path))
+	return c.NoContent(StatusNoContent)
+}
+
 // HTTPHandlerFunc adapts a http.HandlerFunc into a makross.Handler.
 func HTTPHandlerFunc(h http.HandlerFunc) Handler {
 	return func(c *Context) error {
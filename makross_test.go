@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -79,8 +80,10 @@ func TestRouterFind(t *testing.T) {
 	r := New()
 	r.add("GET", "/users/<id>", []Handler{NotFoundHandler})
 	pvalues := make([]string, 10)
-	handlers, pnames := r.find("GET", "/users/1", pvalues)
-	assert.Equal(t, 1, len(handlers))
+	route, pnames := r.currentTable().lookup("GET", "", "/users/1", pvalues, nil)
+	if assert.NotNil(t, route) {
+		assert.Equal(t, 1, len(route.handlers))
+	}
 	if assert.Equal(t, 1, len(pnames)) {
 		assert.Equal(t, "id", pnames[0])
 	}
@@ -100,6 +103,69 @@ func TestRouterHandleError(t *testing.T) {
 	assert.Equal(t, StatusNotFound, res.Code)
 }
 
+func TestMakrossSetErrorHandler(t *testing.T) {
+	m := New()
+	m.SetErrorHandler(func(c *Context, err error) {
+		c.String("root: "+err.Error(), http.StatusInternalServerError)
+	})
+	admin := m.Group("/admin")
+	admin.SetErrorHandler(func(c *Context, err error) {
+		if he, ok := err.(*HTTPError); ok && he.Status == http.StatusNotFound {
+			DefaultErrorHandler(c, err)
+			return
+		}
+		c.Blob(MIMETextHTMLCharsetUTF8, []byte("<h1>"+err.Error()+"</h1>"), http.StatusInternalServerError)
+	})
+	users := admin.Group("/users")
+	api := m.Group("/api")
+	api.SetErrorHandler(func(c *Context, err error) {
+		c.JSON(map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+	})
+
+	fail := func(c *Context) error {
+		return errors.New("failed")
+	}
+	m.Get("/home", fail)
+	admin.Get("/dashboard", fail)
+	admin.Get("/missing", func(c *Context) error {
+		return ErrNotFound
+	})
+	users.Get("/list", fail)
+	api.Get("/users", fail)
+	api.Get("/timeout", Timeout(time.Millisecond), func(c *Context) error {
+		<-c.Kontext().Done()
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	tests := []struct {
+		path, body string
+	}{
+		{"/home", "root: failed"},
+		{"/admin/dashboard", "<h1>failed</h1>"},
+		{"/admin/missing", "Not Found"},
+		{"/admin/users/list", "<h1>failed</h1>"},
+		{"/api/users", `{"error":"failed"}`},
+		{"/api/timeout", `{"error":"Service Unavailable"}`},
+		{"/unknown", "root: Not Found"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		m.ServeHTTP(res, req)
+		assert.Equal(t, test.body, res.Body.String(), test.path)
+	}
+
+	// the default handler is used without error handlers
+	m = New()
+	m.Get("/home", fail)
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/home", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusInternalServerError, res.Code)
+	assert.Equal(t, "failed", res.Body.String())
+}
+
 func TestHTTPHandler(t *testing.T) {
 	res := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/users/", nil)
@@ -119,3 +185,107 @@ func TestHTTPHandler(t *testing.T) {
 	assert.Nil(t, h2(c))
 	assert.Equal(t, StatusNotFound, res.Code)
 }
+
+func TestRouterHost(t *testing.T) {
+	m := New()
+	m.Get("/users", func(c *Context) error {
+		return c.String("default")
+	})
+	m.Host("api.example.com").Get("/users", func(c *Context) error {
+		return c.String("api")
+	})
+	m.Host("<tenant>.example.com").Get("/users/<id>", func(c *Context) error {
+		return c.String(c.Param("tenant").String() + ":" + c.Param("id").String())
+	})
+
+	tests := []struct {
+		host, path, body string
+		code             int
+	}{
+		{"api.example.com", "/users", "api", StatusOK},
+		{"API.example.com:8080", "/users", "api", StatusOK},
+		{"www.example.org", "/users", "default", StatusOK},
+		{"acme.example.com", "/users/7", "acme:7", StatusOK},
+		{"a.b.example.com", "/users/7", "", StatusNotFound},
+		{"www.example.org", "/users/7", "", StatusNotFound},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "http://"+test.host+test.path, nil)
+		m.ServeHTTP(res, req)
+		assert.Equal(t, test.code, res.Code, test.host+test.path)
+		if test.code == StatusOK {
+			assert.Equal(t, test.body, res.Body.String(), test.host+test.path)
+		}
+	}
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "http://acme.example.com/users/7", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, "GET, OPTIONS", res.Header().Get("Allow"), "Allow header")
+	assert.Equal(t, StatusMethodNotAllowed, res.Code, "HTTP status code")
+}
+
+func TestRouterAutoMethods(t *testing.T) {
+	m := New()
+	m.Use(func(c *Context) error {
+		c.Response.Header().Set("X-Global", "1")
+		return nil
+	})
+	m.Get("/users", func(c *Context) error {
+		return c.String("users")
+	})
+	m.Post("/users", func(c *Context) error {
+		return c.String("created")
+	})
+	m.Get("/posts", func(c *Context) error {
+		return c.String("posts")
+	}).Head(func(c *Context) error {
+		return c.NoContent(StatusAccepted)
+	}).Options(func(c *Context) error {
+		return c.String("options")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("HEAD", "/users", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusMethodNotAllowed, res.Code, "HEAD without auto methods")
+
+	m.SetAutoMethods(true)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("HEAD", "/users", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusOK, res.Code)
+	assert.Equal(t, "", res.Body.String())
+	assert.Equal(t, MIMETextPlainCharsetUTF8, res.Header().Get(HeaderContentType))
+	assert.Equal(t, "1", res.Header().Get("X-Global"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("OPTIONS", "/users", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusNoContent, res.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", res.Header().Get(HeaderAllow))
+	assert.Equal(t, "1", res.Header().Get("X-Global"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/users", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusMethodNotAllowed, res.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", res.Header().Get(HeaderAllow))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("HEAD", "/posts", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusAccepted, res.Code, "explicit HEAD route")
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("OPTIONS", "/posts", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, "options", res.Body.String(), "explicit OPTIONS route")
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("OPTIONS", "/comments", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusNotFound, res.Code)
+}
@@ -0,0 +1,141 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"sync"
+)
+
+type (
+	// FormatWriter writes data in a specific format to the response with the given status.
+	FormatWriter func(c *Context, data interface{}, status int) error
+
+	// Template is the data given to Context.Negotiate to render an HTML template when HTML is negotiated.
+	// The template is rendered by Context.Render with the data set in the context, while Data is
+	// written for the other formats.
+	Template struct {
+		Name string
+		Data interface{}
+	}
+)
+
+var (
+	// DefaultOffers lists the MIME types offered by Context.Negotiate when no offer is given.
+	DefaultOffers = []string{MIMEApplicationJSON, MIMEApplicationXML, MIMEApplicationMsgpack, MIMEApplicationProtobuf}
+
+	formatWriters = map[string]FormatWriter{
+		MIMEApplicationJSON:     writeJSONFormat,
+		MIMEApplicationXML:      writeXMLFormat,
+		MIMETextXML:             writeXMLFormat,
+		MIMETextHTML:            writeHTMLFormat,
+		MIMETextPlain:           writeTextFormat,
+		MIMEApplicationMsgpack:  writeMsgpackFormat,
+		MIME_MSGPACK2:           writeMsgpackFormat,
+		MIMEApplicationProtobuf: writeProtobufFormat,
+		MIME_PROTOBUF2:          writeProtobufFormat,
+	}
+	formatWritersMu sync.RWMutex
+)
+
+// RegisterFormat registers the writer used by Context.Negotiate for the given MIME type.
+// JSON, XML, HTML, plain text, MessagePack and Protobuf are registered by default.
+func RegisterFormat(mimeType string, w FormatWriter) {
+	formatWritersMu.Lock()
+	defer formatWritersMu.Unlock()
+	formatWriters[mimeType] = w
+}
+
+// LookupFormat returns the writer registered for the given MIME type, or nil if there is none.
+func LookupFormat(mimeType string) FormatWriter {
+	formatWritersMu.RLock()
+	defer formatWritersMu.RUnlock()
+	return formatWriters[mimeType]
+}
+
+// Negotiate writes the data in the format that best matches the Accept header of the request
+// among the offered MIME types (DefaultOffers if none is given). The first offer is used if the
+// request has no Accept header, and a 406 (Not Acceptable) HTTPError is returned if none of the
+// offers is acceptable. The data is written by the writer registered for the chosen MIME type
+// with RegisterFormat.
+func (c *Context) Negotiate(data interface{}, offers ...string) error {
+	return c.NegotiateStatus(StatusOK, data, offers...)
+}
+
+// NegotiateStatus is the same as Negotiate, except that the response is written with the given status.
+func (c *Context) NegotiateStatus(status int, data interface{}, offers ...string) error {
+	if len(offers) == 0 {
+		offers = DefaultOffers
+	}
+	c.Response.Header().Add(HeaderVary, HeaderAccept)
+
+	format := offers[0]
+	if len(c.Request.Header[HeaderAccept]) > 0 {
+		if format = NegotiateContentType(c.Request, offers, ""); format == "" {
+			return NewHTTPError(StatusNotAcceptable)
+		}
+	}
+	mimeType := strings.TrimSpace(strings.SplitN(format, ";", 2)[0])
+	w := LookupFormat(mimeType)
+	if w == nil {
+		return errors.New("no writer is registered for " + mimeType)
+	}
+	if t, ok := data.(Template); ok && mimeType != MIMETextHTML {
+		data = t.Data
+	}
+	return w(c, data, status)
+}
+
+func writeJSONFormat(c *Context, data interface{}, status int) error {
+	return c.JSON(data, status)
+}
+
+func writeXMLFormat(c *Context, data interface{}, status int) error {
+	return c.XML(data, status)
+}
+
+// writeHTMLFormat renders a Template, and writes any other data HTML-escaped so that it cannot inject markup.
+func writeHTMLFormat(c *Context, data interface{}, status int) error {
+	var s string
+	switch d := data.(type) {
+	case Template:
+		return c.Render(d.Name, status)
+	case string:
+		s = d
+	case []byte:
+		s = string(d)
+	default:
+		s = fmt.Sprint(data)
+	}
+	return c.Blob(MIMETextHTMLCharsetUTF8, []byte(html.EscapeString(s)), status)
+}
+
+func writeTextFormat(c *Context, data interface{}, status int) error {
+	switch d := data.(type) {
+	case string:
+		return c.String(d, status)
+	case []byte:
+		return c.String(string(d), status)
+	}
+	return c.String(fmt.Sprint(data), status)
+}
+
+func writeMsgpackFormat(c *Context, data interface{}, status int) error {
+	return c.Msgpack(data, status)
+}
+
+// writeProtobufFormat rejects data that is not a protocol buffer message as not acceptable,
+// as Protobuf may be offered for any data by DefaultOffers.
+func writeProtobufFormat(c *Context, data interface{}, status int) error {
+	b, err := ProtobufMarshal(data)
+	if err == ErrNotProtoMessage {
+		return NewHTTPError(StatusNotAcceptable)
+	}
+	if err != nil {
+		return err
+	}
+	return c.Blob(MIMEApplicationProtobuf, b, status)
+}
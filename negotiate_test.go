@@ -0,0 +1,92 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type negotiateRenderer struct{}
+
+func (r *negotiateRenderer) Render(w io.Writer, name string, c *Context) error {
+	_, err := io.WriteString(w, "<h1>"+name+" "+c.Get("title").(string)+"</h1>")
+	return err
+}
+
+func negotiate(m *Makross, accept string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	if accept != "" {
+		req.Header.Set(HeaderAccept, accept)
+	}
+	m.ServeHTTP(res, req)
+	return res
+}
+
+func TestContextNegotiate(t *testing.T) {
+	m := New()
+	m.SetRenderer(&negotiateRenderer{})
+	m.Get("/users/1", func(c *Context) error {
+		c.Set("title", "John")
+		data := Template{Name: "user", Data: &user{1, "John"}}
+		return c.Negotiate(data, MIMEApplicationJSON, MIMEApplicationXML, MIMETextHTML, "application/vnd.user")
+	})
+	RegisterFormat("application/vnd.user", func(c *Context, data interface{}, status int) error {
+		return c.Blob("application/vnd.user", []byte(data.(*user).Name), status)
+	})
+	defer delete(formatWriters, "application/vnd.user")
+
+	tests := []struct {
+		accept, contentType, body string
+	}{
+		{"", MIMEApplicationJSONCharsetUTF8, `{"id":1,"name":"John"}`},
+		{"application/xml", MIMEApplicationXMLCharsetUTF8, `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<user><id>1</id><name>John</name></user>`},
+		{"text/html,application/xhtml+xml,*/*;q=0.8", MIMETextHTMLCharsetUTF8, "<h1>user John</h1>"},
+		{"application/vnd.user", "application/vnd.user", "John"},
+		{"*/*", MIMEApplicationJSONCharsetUTF8, `{"id":1,"name":"John"}`},
+	}
+	for _, test := range tests {
+		res := negotiate(m, test.accept)
+		assert.Equal(t, http.StatusOK, res.Code, test.accept)
+		assert.Equal(t, test.contentType, res.Header().Get(HeaderContentType), test.accept)
+		assert.Equal(t, test.body, res.Body.String(), test.accept)
+		assert.Equal(t, HeaderAccept, res.Header().Get(HeaderVary), test.accept)
+	}
+
+	res := negotiate(m, "image/png")
+	assert.Equal(t, StatusNotAcceptable, res.Code)
+}
+
+func TestContextNegotiateStatus(t *testing.T) {
+	m := New()
+	m.Get("/users/1", func(c *Context) error {
+		return c.NegotiateStatus(StatusCreated, "created", MIMETextPlain, MIMETextHTML, "application/unknown")
+	})
+
+	res := negotiate(m, "text/plain")
+	assert.Equal(t, StatusCreated, res.Code)
+	assert.Equal(t, MIMETextPlainCharsetUTF8, res.Header().Get(HeaderContentType))
+	assert.Equal(t, "created", res.Body.String())
+
+	res = negotiate(m, "text/html")
+	assert.Equal(t, MIMETextHTMLCharsetUTF8, res.Header().Get(HeaderContentType))
+	assert.Equal(t, "created", res.Body.String())
+
+	// data that is not a Template is escaped when written as HTML
+	m.Get("/users/2", func(c *Context) error {
+		return c.Negotiate("<script>alert(1)</script>", MIMETextHTML)
+	})
+	res = httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/users/2", nil))
+	assert.Equal(t, MIMETextHTMLCharsetUTF8, res.Header().Get(HeaderContentType))
+	assert.Equal(t, "&lt;script&gt;alert(1)&lt;/script&gt;", res.Body.String())
+
+	res = negotiate(m, "application/unknown")
+	assert.Equal(t, StatusInternalServerError, res.Code)
+	assert.Nil(t, LookupFormat("application/unknown"))
+}
@@ -0,0 +1,48 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentNegotiationWildcard(t *testing.T) {
+	header := http.Header{}
+	req := &http.Request{Header: header}
+	offers := []string{"application/json", "application/xml", "text/plain"}
+
+	header.Set("Accept", "*/*")
+	assert.Equal(t, "application/json", NegotiateContentType(req, offers, ""))
+
+	header.Set("Accept", "text/*")
+	assert.Equal(t, "text/plain", NegotiateContentType(req, offers, ""))
+
+	header.Set("Accept", "application/json;q=0, */*;q=0.1")
+	assert.Equal(t, "application/xml", NegotiateContentType(req, offers, ""))
+
+	header.Set("Accept", "image/png")
+	assert.Equal(t, "", NegotiateContentType(req, offers, ""))
+}
+
+func TestContentNegotiationRejected(t *testing.T) {
+	header := http.Header{}
+	req := &http.Request{Header: header}
+	offers := []string{"application/json", "application/xml", "text/plain"}
+
+	// q=0 makes a media range not acceptable, even when it also matches a wildcard
+	header.Set("Accept", "application/json;q=0")
+	assert.Equal(t, "text/html", NegotiateContentType(req, offers, "text/html"))
+
+	header.Set("Accept", "*/*, application/*;q=0")
+	assert.Equal(t, "text/plain", NegotiateContentType(req, offers, ""))
+
+	header.Set("Accept", "*/*;q=0")
+	assert.Equal(t, "", NegotiateContentType(req, offers, ""))
+
+	// an exact match still wins over the wildcard preference for earlier offers
+	header.Set("Accept", "*/*, text/plain")
+	assert.Equal(t, "text/plain", NegotiateContentType(req, offers, ""))
+}
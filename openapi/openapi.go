@@ -0,0 +1,357 @@
+// Package openapi generates OpenAPI 3 documents from the routes registered with a makross.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/insionng/makross"
+)
+
+// Version is the OpenAPI specification version of the generated documents.
+const Version = "3.0.3"
+
+type (
+	// Document is the root object of an OpenAPI document.
+	Document struct {
+		OpenAPI    string               `json:"openapi"`
+		Info       Info                 `json:"info"`
+		Servers    []Server             `json:"servers,omitempty"`
+		Paths      map[string]*PathItem `json:"paths"`
+		Components *Components          `json:"components,omitempty"`
+	}
+
+	// Info provides metadata about the API.
+	Info struct {
+		Title       string `json:"title"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version"`
+	}
+
+	// Server represents a server hosting the API.
+	Server struct {
+		URL         string `json:"url"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// PathItem describes the operations available on a single path.
+	PathItem struct {
+		Get     *Operation `json:"get,omitempty"`
+		Put     *Operation `json:"put,omitempty"`
+		Post    *Operation `json:"post,omitempty"`
+		Delete  *Operation `json:"delete,omitempty"`
+		Options *Operation `json:"options,omitempty"`
+		Head    *Operation `json:"head,omitempty"`
+		Patch   *Operation `json:"patch,omitempty"`
+		Trace   *Operation `json:"trace,omitempty"`
+	}
+
+	// Operation describes a single API operation on a path.
+	Operation struct {
+		Tags        []string             `json:"tags,omitempty"`
+		Summary     string               `json:"summary,omitempty"`
+		Description string               `json:"description,omitempty"`
+		OperationID string               `json:"operationId,omitempty"`
+		Parameters  []*Parameter         `json:"parameters,omitempty"`
+		RequestBody *RequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*Response `json:"responses"`
+		Deprecated  bool                 `json:"deprecated,omitempty"`
+	}
+
+	// Parameter describes a single operation parameter.
+	Parameter struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"`
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required,omitempty"`
+		Schema      *Schema `json:"schema,omitempty"`
+	}
+
+	// RequestBody describes a single request body.
+	RequestBody struct {
+		Description string                `json:"description,omitempty"`
+		Required    bool                  `json:"required,omitempty"`
+		Content     map[string]*MediaType `json:"content"`
+	}
+
+	// Response describes a single response from an API operation.
+	Response struct {
+		Description string                `json:"description"`
+		Content     map[string]*MediaType `json:"content,omitempty"`
+	}
+
+	// MediaType provides the schema for a media type.
+	MediaType struct {
+		Schema *Schema `json:"schema,omitempty"`
+	}
+
+	// Components holds the reusable schemas referenced from the document.
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas,omitempty"`
+	}
+
+	// Doc describes an operation. Attach it to a route with Route.Tag:
+	//
+	//	m.Post("/users", createUser).Tag(openapi.Doc{
+	//		Summary:  "Create a user",
+	//		Request:  CreateUserReq{},
+	//		Response: User{},
+	//	})
+	//
+	// Request, Response, Query and the values of Responses are only used for their types.
+	Doc struct {
+		Summary     string
+		Description string
+		OperationID string
+		Tags        []string
+		Deprecated  bool
+
+		// Query is a struct whose fields tagged with "query" are documented as query parameters.
+		Query interface{}
+		// Request describes the request body.
+		Request interface{}
+		// Response describes the body of a successful response.
+		Response interface{}
+		// Status is the status code of a successful response.
+		// Optional. Default value makross.StatusOK.
+		Status int
+		// Responses describes the bodies of other responses, keyed by status code.
+		Responses map[int]interface{}
+		// Consumes lists the media types of the request body.
+		// Optional. Default value []string{makross.MIMEApplicationJSON}.
+		Consumes []string
+		// Produces lists the media types of the response bodies.
+		// Optional. Default value []string{makross.MIMEApplicationJSON}.
+		Produces []string
+	}
+)
+
+// Generate builds an OpenAPI document describing all routes registered with the given makross.
+// Routes tagged with a Doc are described by it; other routes are listed with their path parameters only.
+func Generate(m *makross.Makross, info Info) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+	}
+	schemas := newSchemaBuilder()
+	for _, route := range m.Routes() {
+		path, params := convertPath(route.Path())
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+		}
+		op := buildOperation(route, params, schemas)
+		if !item.set(route.Method(), op) {
+			continue
+		}
+		doc.Paths[path] = item
+	}
+	if len(schemas.schemas) > 0 {
+		doc.Components = &Components{Schemas: schemas.schemas}
+	}
+	return doc
+}
+
+func buildOperation(route *makross.Route, params []*Parameter, schemas *schemaBuilder) *Operation {
+	op := &Operation{
+		Parameters: params,
+		Responses:  make(map[string]*Response),
+	}
+	d := findDoc(route)
+	if d == nil {
+		op.Responses["default"] = &Response{Description: "Default response"}
+		return op
+	}
+
+	op.Summary = d.Summary
+	op.Description = d.Description
+	op.OperationID = d.OperationID
+	op.Tags = d.Tags
+	op.Deprecated = d.Deprecated
+
+	consumes, produces := d.Consumes, d.Produces
+	if len(consumes) == 0 {
+		consumes = []string{makross.MIMEApplicationJSON}
+	}
+	if len(produces) == 0 {
+		produces = []string{makross.MIMEApplicationJSON}
+	}
+
+	if d.Query != nil {
+		op.Parameters = append(op.Parameters, schemas.queryParameters(reflect.TypeOf(d.Query))...)
+	}
+	if d.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  buildContent(consumes, schemas.schema(reflect.TypeOf(d.Request))),
+		}
+	}
+
+	status := d.Status
+	if status == 0 {
+		status = makross.StatusOK
+	}
+	op.Responses[strconv.Itoa(status)] = buildResponse(status, d.Response, produces, schemas)
+	for status, body := range d.Responses {
+		op.Responses[strconv.Itoa(status)] = buildResponse(status, body, produces, schemas)
+	}
+	return op
+}
+
+func buildResponse(status int, body interface{}, produces []string, schemas *schemaBuilder) *Response {
+	res := &Response{Description: makross.StatusText(status)}
+	if res.Description == "" {
+		res.Description = strconv.Itoa(status)
+	}
+	if body != nil {
+		res.Content = buildContent(produces, schemas.schema(reflect.TypeOf(body)))
+	}
+	return res
+}
+
+func buildContent(types []string, schema *Schema) map[string]*MediaType {
+	content := make(map[string]*MediaType, len(types))
+	for _, t := range types {
+		content[t] = &MediaType{Schema: schema}
+	}
+	return content
+}
+
+// findDoc returns the Doc tagged to the route, or nil if there is none.
+func findDoc(route *makross.Route) *Doc {
+	for _, tag := range route.Tags() {
+		switch d := tag.(type) {
+		case Doc:
+			return &d
+		case *Doc:
+			return d
+		}
+	}
+	return nil
+}
+
+// set assigns the operation to the given HTTP method.
+// It returns false if the method cannot be described by OpenAPI.
+func (p *PathItem) set(method string, op *Operation) bool {
+	switch method {
+	case makross.GET:
+		p.Get = op
+	case makross.PUT:
+		p.Put = op
+	case makross.POST:
+		p.Post = op
+	case makross.DELETE:
+		p.Delete = op
+	case makross.OPTIONS:
+		p.Options = op
+	case makross.HEAD:
+		p.Head = op
+	case makross.PATCH:
+		p.Patch = op
+	case makross.TRACE:
+		p.Trace = op
+	default:
+		return false
+	}
+	return true
+}
+
+// convertPath converts a makross route path into an OpenAPI path template and its path parameters.
+// For example, "/users/<id:\d+>" becomes "/users/{id}".
+func convertPath(path string) (string, []*Parameter) {
+	var params []*Parameter
+	if strings.HasSuffix(path, "*") {
+		path = path[:len(path)-1] + "<path:.*>"
+	}
+	result, start, end := "", -1, -1
+	for i := 0; i < len(path); i++ {
+		if path[i] == '<' && start < 0 {
+			start = i
+		} else if path[i] == '>' && start >= 0 {
+			name, pattern := path[start+1:i], ""
+			if j := strings.IndexByte(name, ':'); j >= 0 {
+				name, pattern = name[:j], name[j+1:]
+			}
+			if name == "" {
+				name = "param" + strconv.Itoa(len(params)+1)
+			}
+			params = append(params, &Parameter{Name: name, In: "path", Required: true, Schema: paramSchema(pattern)})
+			result += path[end+1:start] + "{" + name + "}"
+			end = i
+			start = -1
+		}
+	}
+	return result + path[end+1:], params
+}
+
+// paramSchema returns the schema of a path parameter with the given pattern,
+// which is either a regular expression or the name of a registered parameter type.
+func paramSchema(pattern string) *Schema {
+	switch pattern {
+	case "", ".*":
+		return &Schema{Type: "string"}
+	case "int":
+		return &Schema{Type: "integer", Format: "int64"}
+	case "uint":
+		return &Schema{Type: "integer", Format: "int64", Minimum: new(float64)}
+	case "float":
+		return &Schema{Type: "number", Format: "double"}
+	case "uuid":
+		return &Schema{Type: "string", Format: "uuid"}
+	case "date":
+		return &Schema{Type: "string", Format: "date"}
+	}
+	if t, ok := makross.LookupParamType(pattern); ok {
+		pattern = t.Pattern
+	}
+	return &Schema{Type: "string", Pattern: "^" + pattern + "$"}
+}
+
+// ServeJSON returns a handler that serves the OpenAPI document of the current makross in JSON format.
+// The document is generated on every request so that it always reflects the registered routes.
+func ServeJSON(info Info) makross.Handler {
+	return func(c *makross.Context) error {
+		return c.JSON(Generate(c.Makross(), info))
+	}
+}
+
+// ServeYAML returns a handler that serves the OpenAPI document of the current makross in YAML format.
+func ServeYAML(info Info) makross.Handler {
+	return func(c *makross.Context) error {
+		b, err := Generate(c.Makross(), info).MarshalYAML()
+		if err != nil {
+			return err
+		}
+		return c.Blob("application/yaml; charset=utf-8", b)
+	}
+}
+
+// ServeUI returns a handler that serves a Swagger UI page displaying the document found at specURL.
+func ServeUI(title, specURL string) makross.Handler {
+	page := strings.NewReplacer("{{title}}", htmlEscaper.Replace(title), "{{url}}", strconv.Quote(specURL)).Replace(uiTemplate)
+	return func(c *makross.Context) error {
+		return c.Blob(makross.MIMETextHTMLCharsetUTF8, []byte(page), http.StatusOK)
+	}
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&#34;", "'", "&#39;")
+
+const uiTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{title}}</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+window.ui = SwaggerUIBundle({url: {{url}}, dom_id: "#swagger-ui"});
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,195 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/insionng/makross"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	address struct {
+		City string `json:"city"`
+	}
+
+	user struct {
+		ID       int64     `json:"id"`
+		Name     string    `json:"name" description:"full name"`
+		Tags     []string  `json:"tags,omitempty"`
+		Address  *address  `json:"address"`
+		Friends  []*user   `json:"friends"`
+		Created  time.Time `json:"created"`
+		Password string    `json:"-"`
+		internal string
+	}
+
+	listQuery struct {
+		Page  int    `query:"page" description:"page number"`
+		Order string `query:"order"`
+		Other string
+	}
+)
+
+func newTestMakross() *makross.Makross {
+	m := makross.New()
+	h := func(c *makross.Context) error { return nil }
+	m.Get("/users", h).Tag(Doc{Summary: "List users", Query: listQuery{}, Response: []user{}})
+	m.Post("/users", h).Tag(&Doc{Summary: "Create a user", Request: user{}, Response: user{}, Status: makross.StatusCreated,
+		Responses: map[int]interface{}{makross.StatusBadRequest: makross.HTTPError{}}})
+	m.Get(`/users/<id:\d+>`, h)
+	m.Get("/files/*", h)
+	return m
+}
+
+func TestConvertPath(t *testing.T) {
+	tests := []struct {
+		path, expected string
+		params         []string
+	}{
+		{"/users", "/users", nil},
+		{"/users/<id>", "/users/{id}", []string{"id"}},
+		{`/users/<id:\d+>/posts/<pid>`, "/users/{id}/posts/{pid}", []string{"id", "pid"}},
+		{`/users/<:\d+>`, "/users/{param1}", []string{"param1"}},
+		{"/files/*", "/files/{path}", []string{"path"}},
+	}
+	for _, test := range tests {
+		path, params := convertPath(test.path)
+		assert.Equal(t, test.expected, path, "convertPath("+test.path+") =")
+		if assert.Equal(t, len(test.params), len(params), "len(params) =") {
+			for i, p := range params {
+				assert.Equal(t, test.params[i], p.Name)
+				assert.Equal(t, "path", p.In)
+				assert.True(t, p.Required)
+			}
+		}
+	}
+	_, params := convertPath(`/users/<id:\d+>/<n:int>/<d:date>`)
+	assert.Equal(t, `^\d+$`, params[0].Schema.Pattern)
+	assert.Equal(t, "integer", params[1].Schema.Type)
+	assert.Equal(t, "", params[1].Schema.Pattern)
+	assert.Equal(t, "date", params[2].Schema.Format)
+}
+
+func TestGenerate(t *testing.T) {
+	doc := Generate(newTestMakross(), Info{Title: "Test", Version: "1.0"})
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.Equal(t, "Test", doc.Info.Title)
+	assert.Equal(t, 3, len(doc.Paths))
+
+	list := doc.Paths["/users"].Get
+	if assert.NotNil(t, list) {
+		assert.Equal(t, "List users", list.Summary)
+		if assert.Equal(t, 2, len(list.Parameters)) {
+			assert.Equal(t, "page", list.Parameters[0].Name)
+			assert.Equal(t, "query", list.Parameters[0].In)
+			assert.Equal(t, "integer", list.Parameters[0].Schema.Type)
+			assert.Equal(t, "int64", list.Parameters[0].Schema.Format)
+			assert.Equal(t, "page number", list.Parameters[0].Description)
+		}
+		schema := list.Responses["200"].Content[makross.MIMEApplicationJSON].Schema
+		assert.Equal(t, "array", schema.Type)
+		assert.Equal(t, "#/components/schemas/user", schema.Items.Ref)
+	}
+
+	create := doc.Paths["/users"].Post
+	if assert.NotNil(t, create) {
+		assert.NotNil(t, create.RequestBody)
+		assert.NotNil(t, create.Responses["201"])
+		assert.Equal(t, "Bad Request", create.Responses["400"].Description)
+	}
+
+	show := doc.Paths["/users/{id}"].Get
+	if assert.NotNil(t, show) {
+		assert.Equal(t, 1, len(show.Parameters))
+		assert.NotNil(t, show.Responses["default"])
+	}
+
+	u := doc.Components.Schemas["user"]
+	if assert.NotNil(t, u) {
+		assert.Equal(t, []string{"address", "created", "friends", "id", "name", "tags"}, keys(u.Properties))
+		assert.Equal(t, "int64", u.Properties["id"].Format)
+		assert.Equal(t, "full name", u.Properties["name"].Description)
+		assert.Equal(t, "date-time", u.Properties["created"].Format)
+		assert.Equal(t, "#/components/schemas/address", u.Properties["address"].Ref)
+		assert.Equal(t, "#/components/schemas/user", u.Properties["friends"].Items.Ref)
+	}
+	assert.NotNil(t, doc.Components.Schemas["HTTPError"])
+}
+
+func TestSchemaIntegerFormats(t *testing.T) {
+	b := newSchemaBuilder()
+	for _, v := range []interface{}{int8(0), int16(0), int32(0), uint8(0), uint16(0)} {
+		assert.Equal(t, "int32", b.schema(reflect.TypeOf(v)).Format, "%T", v)
+	}
+	for _, v := range []interface{}{0, int64(0), uint(0), uint32(0), uint64(0)} {
+		assert.Equal(t, "int64", b.schema(reflect.TypeOf(v)).Format, "%T", v)
+	}
+}
+
+func TestServe(t *testing.T) {
+	m := newTestMakross()
+	m.Get("/openapi.json", ServeJSON(Info{Title: "Test", Version: "1.0"}))
+	m.Get("/openapi.yaml", ServeYAML(Info{Title: "Test", Version: "1.0"}))
+	m.Get("/docs", ServeUI("Test <API>", "/openapi.json"))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, makross.StatusOK, res.Code)
+	var doc Document
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &doc))
+	assert.NotNil(t, doc.Paths["/openapi.json"])
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/openapi.yaml", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, makross.StatusOK, res.Code)
+	assert.True(t, strings.HasPrefix(res.Body.String(), "components:\n"))
+	assert.Contains(t, res.Body.String(), "\n  \"/users/{id}\":\n    get:\n")
+	assert.Contains(t, res.Body.String(), "\n        \"201\":\n")
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/docs", nil)
+	m.ServeHTTP(res, req)
+	assert.Contains(t, res.Body.String(), "<title>Test &lt;API&gt;</title>")
+	assert.Contains(t, res.Body.String(), `url: "/openapi.json"`)
+}
+
+func TestMarshalYAML(t *testing.T) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: "a: b", Version: "1"},
+		Servers: []Server{{URL: "/"}, {URL: "/v2", Description: "next"}},
+		Paths:   map[string]*PathItem{},
+	}
+	b, err := doc.MarshalYAML()
+	assert.Nil(t, err)
+	assert.Equal(t, `info:
+  title: "a: b"
+  version: "1"
+openapi: "3.0.3"
+paths: {}
+servers:
+  -
+    url: "/"
+  -
+    description: "next"
+    url: "/v2"
+`, string(b))
+}
+
+func keys(m map[string]*Schema) []string {
+	var r []string
+	for k := range m {
+		r = append(r, k)
+	}
+	sort.Strings(r)
+	return r
+}
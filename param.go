@@ -0,0 +1,68 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"strconv"
+	"time"
+)
+
+// ParamType describes a named type of route parameters.
+// A parameter token whose pattern is the name of a registered type, such as "<id:int>",
+// only matches the values accepted by the type.
+type ParamType struct {
+	// Pattern is the regular expression that a parameter value must match.
+	Pattern string
+
+	// Convert converts a matched parameter value into a typed value.
+	// A value that cannot be converted does not match the route.
+	// Optional. The value is kept as a string if nil.
+	Convert func(string) (interface{}, error)
+}
+
+// paramTypes lists all registered parameter types by name.
+var paramTypes = map[string]*ParamType{
+	"int": {
+		Pattern: `[-+]?[0-9]+`,
+		Convert: func(s string) (interface{}, error) {
+			v, err := strconv.ParseInt(s, 10, 0)
+			return int(v), err
+		},
+	},
+	"uint": {
+		Pattern: `[0-9]+`,
+		Convert: func(s string) (interface{}, error) {
+			v, err := strconv.ParseUint(s, 10, 0)
+			return uint(v), err
+		},
+	},
+	"float": {
+		Pattern: `[-+]?[0-9]+(\.[0-9]+)?`,
+		Convert: func(s string) (interface{}, error) {
+			return strconv.ParseFloat(s, 64)
+		},
+	},
+	"uuid": {
+		Pattern: `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	},
+	"date": {
+		Pattern: `[0-9]{4}-[0-9]{2}-[0-9]{2}`,
+		Convert: func(s string) (interface{}, error) {
+			return time.Parse("2006-01-02", s)
+		},
+	},
+}
+
+// RegisterParamType registers a parameter type with the given name, replacing the existing one, if any.
+// Parameter types should be registered before adding the routes that use them.
+func RegisterParamType(name string, t ParamType) {
+	paramTypes[name] = &t
+}
+
+// LookupParamType returns the parameter type registered with the given name.
+func LookupParamType(name string) (ParamType, bool) {
+	if t := paramTypes[name]; t != nil {
+		return *t, true
+	}
+	return ParamType{}, false
+}
@@ -0,0 +1,124 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterParamType(t *testing.T) {
+	_, ok := LookupParamType("hex")
+	assert.False(t, ok)
+
+	RegisterParamType("hex", ParamType{Pattern: "[0-9a-f]+"})
+	defer delete(paramTypes, "hex")
+	pt, ok := LookupParamType("hex")
+	assert.True(t, ok)
+	assert.Equal(t, "[0-9a-f]+", pt.Pattern)
+
+	m := New()
+	m.Get("/colors/<c:hex>", func(c *Context) error {
+		return c.String(c.Param("c").String())
+	})
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/colors/ff00ff", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, "ff00ff", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/colors/red", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusNotFound, res.Code)
+}
+
+func TestTypedParamsConvertedOnce(t *testing.T) {
+	var conversions int
+	RegisterParamType("counted", ParamType{
+		Pattern: "[0-9]+",
+		Convert: func(s string) (interface{}, error) {
+			conversions++
+			return strconv.Atoi(s)
+		},
+	})
+	defer delete(paramTypes, "counted")
+
+	m := New()
+	m.Get("/items/<id:counted>", func(c *Context) error {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, 42, c.Param("id").MustInt())
+			assert.Equal(t, 42, c.Args("id").Value())
+		}
+		return nil
+	})
+	m.Get("/items/<id:counted>/<name>", func(c *Context) error {
+		assert.Equal(t, 7, c.Param("id").Value())
+		assert.Equal(t, "seven", c.Param("name").String())
+		return nil
+	})
+
+	// the value converted by the route store while matching the route is reused by Param and Args
+	conversions = 0
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/items/42", nil))
+	assert.Equal(t, StatusOK, res.Code)
+	assert.Equal(t, 1, conversions)
+
+	conversions = 0
+	res = httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/items/7/seven", nil))
+	assert.Equal(t, StatusOK, res.Code)
+	assert.Equal(t, 1, conversions)
+}
+
+func TestTypedParams(t *testing.T) {
+	m := New()
+	m.Get("/users/<id:int>", func(c *Context) error {
+		id, err := c.Param("id").Int()
+		assert.Nil(t, err)
+		assert.Equal(t, id, c.Param("id").Value())
+		return c.String("int")
+	})
+	m.Get("/users/<u:uuid>", func(c *Context) error {
+		return c.String("uuid " + c.Param("u").String())
+	})
+	m.Get("/users/<name>", func(c *Context) error {
+		return c.String("name")
+	})
+	m.Get("/archive/<d:date>", func(c *Context) error {
+		assert.Equal(t, time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC), c.Param("d").Time())
+		return c.String("date")
+	})
+	m.Get("/prices/<p:float>/<n:uint>", func(c *Context) error {
+		assert.Equal(t, 1.5, c.Param("p").MustFloat64())
+		assert.Equal(t, uint(3), c.Param("n").MustUint())
+		return c.String("float")
+	})
+
+	tests := []struct {
+		path, body string
+	}{
+		{"/users/123", "int"},
+		{"/users/-5", "int"},
+		{"/users/99999999999999999999999", "name"},
+		{"/users/12ab", "name"},
+		{"/users/6ba7b810-9dad-11d1-80b4-00c04fd430c8", "uuid 6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+		{"/users/jon", "name"},
+		{"/archive/2017-03-04", "date"},
+		{"/archive/2017-13-04", "Not Found"},
+		{"/prices/1.5/3", "float"},
+		{"/prices/1.5/-3", "Not Found"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		m.ServeHTTP(res, req)
+		assert.Equal(t, test.body, strings.TrimSpace(res.Body.String()), test.path)
+	}
+}
@@ -111,7 +111,7 @@ func (r *Renderer) Render(w io.Writer, name string, c *makross.Context) error {
 	}
 
 	var buffer bytes.Buffer
-	err = template.ExecuteWriter(c.GetStore(), &buffer)
+	err = template.ExecuteWriter(c.TemplateData(), &buffer)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,11 @@
 package pongor_test
 
 import (
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/insionng/makross"
@@ -18,3 +23,31 @@ func TestRender(t *testing.T) {
 		}
 	}())
 }
+
+func TestRenderFlash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pongor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tpl := `{{ Flash.ErrorMsg }}|{% for msg in Flash.Messages("notice") %}{{ msg }};{% endfor %}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "flash.html"), []byte(tpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := makross.New()
+	e.SetRenderer(pongor.Renderor(pongor.Option{Directory: dir}))
+	e.Get("/", func(ctx *makross.Context) error {
+		// the flash is not bound to the context, so it is only found in ctx.Flash
+		flash := &makross.Flash{}
+		flash.Load(url.Values{"error": {"failed"}, "notice": {"check your inbox"}})
+		ctx.Flash = flash
+		return ctx.Render("flash")
+	})
+
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	if body := res.Body.String(); body != "failed|check your inbox;" {
+		t.Errorf("unexpected body %q", body)
+	}
+}
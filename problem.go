@@ -0,0 +1,188 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// problemOffers lists the formats Context.Problem can write, in the order of preference.
+var problemOffers = []string{
+	MIMEApplicationProblemJSON,
+	MIMEApplicationProblemXML,
+	MIMEApplicationJSON,
+	MIMEApplicationXML,
+	MIMETextXML,
+	MIMETextHTML,
+	MIMETextPlain,
+}
+
+// problemMembers lists the standard members of a problem, which cannot be overridden by extensions.
+var problemMembers = map[string]bool{"type": true, "title": true, "status": true, "detail": true, "instance": true}
+
+// WithType returns a copy of the error with the URI identifying the problem type.
+func (e *HTTPError) WithType(uri string) *HTTPError {
+	he := e.clone()
+	he.Type = uri
+	return he
+}
+
+// WithTitle returns a copy of the error with the short summary of the problem type.
+func (e *HTTPError) WithTitle(title string) *HTTPError {
+	he := e.clone()
+	he.Title = title
+	return he
+}
+
+// WithDetail returns a copy of the error with the explanation specific to this occurrence of the problem.
+func (e *HTTPError) WithDetail(detail string) *HTTPError {
+	he := e.clone()
+	he.Detail = detail
+	return he
+}
+
+// WithInstance returns a copy of the error with the URI identifying this occurrence of the problem.
+func (e *HTTPError) WithInstance(uri string) *HTTPError {
+	he := e.clone()
+	he.Instance = uri
+	return he
+}
+
+// WithExtension returns a copy of the error with an additional member of the problem details.
+func (e *HTTPError) WithExtension(name string, value interface{}) *HTTPError {
+	he := e.clone()
+	he.Extensions = make(map[string]interface{}, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		he.Extensions[k] = v
+	}
+	he.Extensions[name] = value
+	return he
+}
+
+// clone returns a shallow copy of the error, so that the predefined errors such as ErrNotFound are never modified.
+func (e *HTTPError) clone() *HTTPError {
+	he := *e
+	return &he
+}
+
+// Problem returns the members of the RFC 7807 problem details of the error.
+// The type defaults to "about:blank", in which case the title is the status text, and
+// the detail defaults to the message if it differs from the status text.
+func (e *HTTPError) Problem() map[string]interface{} {
+	p := make(map[string]interface{}, len(e.Extensions)+5)
+	for k, v := range e.Extensions {
+		if !problemMembers[k] {
+			p[k] = v
+		}
+	}
+	p["type"] = e.Type
+	p["title"] = e.Title
+	if e.Type == "" || e.Type == "about:blank" {
+		p["type"] = "about:blank"
+		p["title"] = StatusText(e.Status)
+	}
+	p["status"] = e.Status
+	if detail := e.Detail; detail != "" {
+		p["detail"] = detail
+	} else if e.Message != "" && e.Message != StatusText(e.Status) {
+		p["detail"] = e.Message
+	}
+	if e.Instance != "" {
+		p["instance"] = e.Instance
+	}
+	if p["title"] == "" {
+		delete(p, "title")
+	}
+	return p
+}
+
+// Problem writes the error as RFC 7807 problem details with the status of the error.
+// The problem is written as application/problem+json or application/problem+xml according to
+// the Accept header of the request (JSON if there is no Accept header), while the message of
+// the error is written as plain text to the clients preferring HTML or plain text.
+func (c *Context) Problem(e *HTTPError) error {
+	c.Response.Header().Add(HeaderVary, HeaderAccept)
+	format := MIMEApplicationProblemJSON
+	if len(c.Request.Header[HeaderAccept]) > 0 {
+		format = NegotiateContentType(c.Request, problemOffers, MIMETextPlain)
+	}
+
+	switch format {
+	case MIMEApplicationProblemJSON, MIMEApplicationJSON:
+		b, err := json.Marshal(e.Problem())
+		if err != nil {
+			return err
+		}
+		return c.Blob(MIMEApplicationProblemJSON, b, e.Status)
+	case MIMEApplicationProblemXML, MIMEApplicationXML, MIMETextXML:
+		b, err := problemXML(e.Problem())
+		if err != nil {
+			return err
+		}
+		return c.Blob(MIMEApplicationProblemXML, b, e.Status)
+	}
+	return c.String(e.Message, e.Status)
+}
+
+// problemXML encodes the problem as described in Appendix A of RFC 7807. Extensions that
+// cannot be encoded as XML, such as maps, are written with their default string format.
+func problemXML(p map[string]interface{}) ([]byte, error) {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		if !problemMembers[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	keys = append([]string{"type", "title", "status", "detail", "instance"}, keys...)
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header + `<problem xmlns="urn:ietf:rfc:7807">`)
+	for _, k := range keys {
+		v, ok := p[k]
+		if !ok {
+			continue
+		}
+		start := xml.StartElement{Name: xml.Name{Local: xmlName(k)}}
+		b, err := encodeXMLElement(v, start)
+		if err != nil {
+			if b, err = encodeXMLElement(fmt.Sprint(v), start); err != nil {
+				return nil, err
+			}
+		}
+		buf.Write(b)
+	}
+	buf.WriteString("</problem>")
+	return buf.Bytes(), nil
+}
+
+func encodeXMLElement(v interface{}, start xml.StartElement) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	if err := enc.EncodeElement(v, start); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// xmlName replaces the characters not allowed in XML element names with underscores.
+// Names not starting with a letter are prefixed with an underscore.
+func xmlName(name string) string {
+	if name == "" || !(name[0] == '_' || 'a' <= name[0] && name[0] <= 'z' || 'A' <= name[0] && name[0] <= 'Z') {
+		name = "_" + name
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || r == '.' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
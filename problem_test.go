@@ -0,0 +1,110 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPErrorProblem(t *testing.T) {
+	e := ErrNotFound.WithDetail("no user 1").WithInstance("/users/1")
+	assert.Equal(t, "", ErrNotFound.Detail)
+	assert.Equal(t, map[string]interface{}{
+		"type":     "about:blank",
+		"title":    "Not Found",
+		"status":   http.StatusNotFound,
+		"detail":   "no user 1",
+		"instance": "/users/1",
+	}, e.Problem())
+
+	e = NewHTTPError(http.StatusForbidden, "not enough credit").
+		WithType("https://example.com/probs/out-of-credit").
+		WithTitle("You do not have enough credit.").
+		WithExtension("balance", 30).
+		WithExtension("status", "ignored")
+	assert.Equal(t, map[string]interface{}{
+		"type":    "https://example.com/probs/out-of-credit",
+		"title":   "You do not have enough credit.",
+		"status":  http.StatusForbidden,
+		"detail":  "not enough credit",
+		"balance": 30,
+	}, e.Problem())
+	assert.Equal(t, "not enough credit", e.Error())
+}
+
+func TestContextProblem(t *testing.T) {
+	e := NewHTTPError(http.StatusForbidden).
+		WithType("https://example.com/probs/out-of-credit").
+		WithTitle("You do not have enough credit.").
+		WithExtension("balance", 30).
+		WithExtension("accounts", []string{"/account/12345", "/account/67890"}).
+		WithExtension("meta", map[string]int{"a": 1})
+
+	tests := []struct {
+		accept, contentType, body string
+	}{
+		{"", MIMEApplicationProblemJSON, `{"accounts":["/account/12345","/account/67890"],"balance":30,"meta":{"a":1},"status":403,"title":"You do not have enough credit.","type":"https://example.com/probs/out-of-credit"}`},
+		{"application/json", MIMEApplicationProblemJSON, `{"accounts":["/account/12345","/account/67890"],"balance":30,"meta":{"a":1},"status":403,"title":"You do not have enough credit.","type":"https://example.com/probs/out-of-credit"}`},
+		{"application/problem+xml", MIMEApplicationProblemXML, `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+			`<problem xmlns="urn:ietf:rfc:7807"><type>https://example.com/probs/out-of-credit</type><title>You do not have enough credit.</title><status>403</status>` +
+			`<accounts>/account/12345</accounts><accounts>/account/67890</accounts><balance>30</balance><meta>map[a:1]</meta></problem>`},
+		{"text/html,application/xhtml+xml,*/*;q=0.8", MIMETextPlainCharsetUTF8, "Forbidden"},
+		{"image/png", MIMETextPlainCharsetUTF8, "Forbidden"},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(GET, "/", nil)
+		if test.accept != "" {
+			req.Header.Set(HeaderAccept, test.accept)
+		}
+		res := httptest.NewRecorder()
+		c := New().NewContext(req, res)
+		assert.Nil(t, c.Problem(e), test.accept)
+		assert.Equal(t, http.StatusForbidden, res.Code, test.accept)
+		assert.Equal(t, test.contentType, res.Header().Get(HeaderContentType), test.accept)
+		assert.Equal(t, test.body, res.Body.String(), test.accept)
+	}
+}
+
+func TestMakrossProblemDetails(t *testing.T) {
+	m := New()
+	m.SetProblemDetails(true)
+	m.Get("/users/<id>", func(c *Context) error {
+		return ErrNotFound.WithDetail("no user " + c.Param("id").String())
+	})
+	m.Get("/panic", func(c *Context) error {
+		return errors.New("database is down")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(GET, "/users/1", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, MIMEApplicationProblemJSON, res.Header().Get(HeaderContentType))
+	assert.Equal(t, `{"detail":"no user 1","status":404,"title":"Not Found","type":"about:blank"}`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest(GET, "/panic", nil)
+	req.Header.Set(HeaderAccept, "text/plain")
+	m.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, "database is down", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest(GET, "/panic", nil)
+	req.Header.Set(HeaderAccept, "application/xml")
+	m.ServeHTTP(res, req)
+	assert.Equal(t, MIMEApplicationProblemXML, res.Header().Get(HeaderContentType))
+	assert.Contains(t, res.Body.String(), "<detail>database is down</detail>")
+
+	// the message is written as plain text when the problem details are disabled
+	m.SetProblemDetails(false)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest(GET, "/users/1", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, "Not Found", res.Body.String())
+}
@@ -0,0 +1,37 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+type (
+	// ProtoMarshaler is implemented by protocol buffer messages that can encode themselves,
+	// such as the messages generated by gogo/protobuf.
+	ProtoMarshaler interface {
+		Marshal() ([]byte, error)
+	}
+
+	// ProtoUnmarshaler is implemented by protocol buffer messages that can decode themselves.
+	ProtoUnmarshaler interface {
+		Unmarshal([]byte) error
+	}
+)
+
+var (
+	// ProtobufMarshal encodes a protocol buffer message for Context.Protobuf.
+	// The default implementation requires the message to implement ProtoMarshaler. You may set it
+	// to a function from a protobuf library, e.g. proto.Marshal with a type assertion to proto.Message.
+	ProtobufMarshal = func(i interface{}) ([]byte, error) {
+		if m, ok := i.(ProtoMarshaler); ok {
+			return m.Marshal()
+		}
+		return nil, ErrNotProtoMessage
+	}
+
+	// ProtobufUnmarshal decodes a protocol buffer message for Bind and ProtobufDataReader.
+	// The default implementation requires the message to implement ProtoUnmarshaler.
+	ProtobufUnmarshal = func(b []byte, i interface{}) error {
+		if m, ok := i.(ProtoUnmarshaler); ok {
+			return m.Unmarshal(b)
+		}
+		return ErrNotProtoMessage
+	}
+)
@@ -0,0 +1,141 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/insionng/makross/libraries/msgpack"
+	"github.com/stretchr/testify/assert"
+)
+
+// protoUser is a fake protocol buffer message encoded as "name:<name>".
+type protoUser struct {
+	Name string
+}
+
+func (u *protoUser) Marshal() ([]byte, error) {
+	return []byte("name:" + u.Name), nil
+}
+
+func (u *protoUser) Unmarshal(b []byte) error {
+	if !bytes.HasPrefix(b, []byte("name:")) {
+		return errors.New("invalid message")
+	}
+	u.Name = string(b[5:])
+	return nil
+}
+
+func TestContextMsgpack(t *testing.T) {
+	m := New()
+	res := httptest.NewRecorder()
+	c := m.NewContext(httptest.NewRequest(GET, "/", nil), res)
+	assert.Nil(t, c.Msgpack(&user{1, "John"}, StatusCreated))
+	assert.Equal(t, StatusCreated, res.Code)
+	assert.Equal(t, MIMEApplicationMsgpack, res.Header().Get(HeaderContentType))
+
+	var u user
+	assert.Nil(t, msgpack.Unmarshal(res.Body.Bytes(), &u))
+	assert.Equal(t, user{1, "John"}, u)
+}
+
+func TestContextProtobuf(t *testing.T) {
+	m := New()
+	res := httptest.NewRecorder()
+	c := m.NewContext(httptest.NewRequest(GET, "/", nil), res)
+	assert.Nil(t, c.Protobuf(&protoUser{"John"}))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, MIMEApplicationProtobuf, res.Header().Get(HeaderContentType))
+	assert.Equal(t, "name:John", res.Body.String())
+
+	c = m.NewContext(httptest.NewRequest(GET, "/", nil), httptest.NewRecorder())
+	assert.Equal(t, ErrNotProtoMessage, c.Protobuf(&user{1, "John"}))
+}
+
+func TestBindMsgpackAndProtobuf(t *testing.T) {
+	b, _ := msgpack.Marshal(&user{1, "Jon Snow"})
+	testBindOkay(t, bytes.NewReader(b), MIMEApplicationMsgpack)
+	testBindOkay(t, bytes.NewReader(b), MIME_MSGPACK2)
+
+	m := New()
+	req := httptest.NewRequest(POST, "/", strings.NewReader("\xc1"))
+	req.Header.Set(HeaderContentType, MIMEApplicationMsgpack)
+	err := m.NewContext(req, httptest.NewRecorder()).Bind(new(user))
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).StatusCode())
+	}
+
+	// deeply nested arrays are rejected instead of overflowing the stack
+	req = httptest.NewRequest(POST, "/", bytes.NewReader(bytes.Repeat([]byte{0x91}, 1<<20)))
+	req.Header.Set(HeaderContentType, MIMEApplicationMsgpack)
+	err = m.NewContext(req, httptest.NewRecorder()).Bind(new(user))
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).StatusCode())
+	}
+
+	req = httptest.NewRequest(POST, "/", strings.NewReader("name:Jon Snow"))
+	req.Header.Set(HeaderContentType, MIMEApplicationProtobuf)
+	u := new(protoUser)
+	assert.Nil(t, m.NewContext(req, httptest.NewRecorder()).Bind(u))
+	assert.Equal(t, "Jon Snow", u.Name)
+
+	req = httptest.NewRequest(POST, "/", strings.NewReader("bad"))
+	req.Header.Set(HeaderContentType, MIME_PROTOBUF2)
+	err = m.NewContext(req, httptest.NewRecorder()).Bind(new(protoUser))
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).StatusCode())
+	}
+
+	req = httptest.NewRequest(POST, "/", strings.NewReader("name:Jon Snow"))
+	req.Header.Set(HeaderContentType, MIMEApplicationProtobuf)
+	assert.Equal(t, ErrUnsupportedMediaType, m.NewContext(req, httptest.NewRecorder()).Bind(new(user)))
+}
+
+func TestReadMsgpackAndProtobuf(t *testing.T) {
+	m := New()
+	b, _ := msgpack.Marshal(&user{1, "John"})
+	req := httptest.NewRequest(POST, "/", bytes.NewReader(b))
+	req.Header.Set(HeaderContentType, MIME_MSGPACK)
+	var u user
+	assert.Nil(t, m.NewContext(req, httptest.NewRecorder()).Read(&u))
+	assert.Equal(t, user{1, "John"}, u)
+
+	req = httptest.NewRequest(POST, "/", strings.NewReader("name:John"))
+	req.Header.Set(HeaderContentType, MIME_PROTOBUF2)
+	var p protoUser
+	assert.Nil(t, m.NewContext(req, httptest.NewRecorder()).Read(&p))
+	assert.Equal(t, "John", p.Name)
+}
+
+func TestContextNegotiateMsgpackAndProtobuf(t *testing.T) {
+	m := New()
+	m.Get("/users/1", func(c *Context) error {
+		return c.Negotiate(&user{1, "John"})
+	})
+	m.Get("/messages/1", func(c *Context) error {
+		return c.Negotiate(&protoUser{"John"})
+	})
+
+	res := negotiate(m, MIMEApplicationMsgpack)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, MIMEApplicationMsgpack, res.Header().Get(HeaderContentType))
+	var u user
+	assert.Nil(t, msgpack.Unmarshal(res.Body.Bytes(), &u))
+	assert.Equal(t, "John", u.Name)
+
+	res = negotiate(m, MIMEApplicationProtobuf)
+	assert.Equal(t, StatusNotAcceptable, res.Code)
+
+	res = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/messages/1", nil)
+	req.Header.Set(HeaderAccept, MIMEApplicationProtobuf)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, MIMEApplicationProtobuf, res.Header().Get(HeaderContentType))
+	assert.Equal(t, "name:John", res.Body.String())
+}
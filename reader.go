@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"io/ioutil"
 	"net/http"
 	"reflect"
 	"strconv"
+
+	"github.com/insionng/makross/libraries/msgpack"
 )
 
 // MIME types used when doing request data reading and response data writing.
@@ -19,6 +22,10 @@ const (
 	MIME_HTML           = "text/html"
 	MIME_FORM           = "application/x-www-form-urlencoded"
 	MIME_MULTIPART_FORM = "multipart/form-data"
+	MIME_MSGPACK        = "application/msgpack"
+	MIME_MSGPACK2       = "application/x-msgpack"
+	MIME_PROTOBUF       = "application/protobuf"
+	MIME_PROTOBUF2      = "application/x-protobuf"
 )
 
 // DataReader is used by Context.Read() to read data from an HTTP request.
@@ -38,6 +45,10 @@ var (
 		MIME_JSON:           &JSONDataReader{},
 		MIME_XML:            &XMLDataReader{},
 		MIME_XML2:           &XMLDataReader{},
+		MIME_MSGPACK:        &MsgpackDataReader{},
+		MIME_MSGPACK2:       &MsgpackDataReader{},
+		MIME_PROTOBUF:       &ProtobufDataReader{},
+		MIME_PROTOBUF2:      &ProtobufDataReader{},
 	}
 	// DefaultFormDataReader is the reader used when there is no matching reader in DataReaders
 	// or if the current request is a GET request.
@@ -58,6 +69,28 @@ func (r *XMLDataReader) Read(req *http.Request, data interface{}) error {
 	return xml.NewDecoder(req.Body).Decode(data)
 }
 
+// MsgpackDataReader reads the request body as MessagePack-formatted data.
+type MsgpackDataReader struct{}
+
+func (r *MsgpackDataReader) Read(req *http.Request, data interface{}) error {
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return msgpack.Unmarshal(b, data)
+}
+
+// ProtobufDataReader reads the request body as a protocol buffer message using ProtobufUnmarshal.
+type ProtobufDataReader struct{}
+
+func (r *ProtobufDataReader) Read(req *http.Request, data interface{}) error {
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return ProtobufUnmarshal(b, data)
+}
+
 // FormDataReader reads the query parameters and request body as form data.
 type FormDataReader struct{}
 
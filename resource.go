@@ -0,0 +1,109 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Resource represents a RESTful resource whose routes are served by the methods of a controller.
+type Resource struct {
+	name       string      // the name of the resource, prefixed with the names of its parents
+	param      string      // the name of the parameter identifying a member in nested resources
+	collection *RouteGroup // the group of the routes under the resource path
+}
+
+// resourceActions lists the controller methods recognized by RouteGroup.Resource.
+// The routes for "new" and "edit" are registered before the ones they would otherwise be shadowed by.
+var resourceActions = []struct {
+	action, method, path string
+}{
+	{"Index", GET, ""},
+	{"New", GET, "/new"},
+	{"Create", POST, ""},
+	{"Show", GET, "/<id>"},
+	{"Edit", GET, "/<id>/edit"},
+	{"Update", PUT, "/<id>"},
+	{"Patch", PATCH, "/<id>"},
+	{"Destroy", DELETE, "/<id>"},
+}
+
+// Resource registers the conventional routes of a RESTful resource for the given controller.
+// The controller may implement any of the following methods, each being a func(*Context) error:
+//
+//	Index    GET    /photos            photos.index
+//	New      GET    /photos/new        photos.new
+//	Create   POST   /photos            photos.create
+//	Show     GET    /photos/<id>       photos.show
+//	Edit     GET    /photos/<id>/edit  photos.edit
+//	Update   PUT    /photos/<id>       photos.update
+//	Patch    PATCH  /photos/<id>       photos.patch
+//	Destroy  DELETE /photos/<id>       photos.destroy
+//
+// Only the routes of the implemented methods are registered, and each route is named after the
+// resource and the action as shown above. The given handlers are
+// run after the ones of the current group. The returned Resource can be used to nest resources.
+func (rg *RouteGroup) Resource(path string, controller interface{}, handlers ...Handler) *Resource {
+	return rg.resource("", path, controller, handlers)
+}
+
+// Resource registers a resource nested in the current one, e.g. "/users/<user_id>/photos" for "/photos"
+// nested in "/users". The routes of the nested resource are named after both resources, e.g. "users.photos.show".
+// See RouteGroup.Resource for the routes registered for the controller.
+func (r *Resource) Resource(path string, controller interface{}, handlers ...Handler) *Resource {
+	member := r.collection.Group("/<"+r.param+">", r.collection.handlers...)
+	return member.resource(r.name+".", path, controller, handlers)
+}
+
+// Collection returns the route group of the resource path, which can be used to add custom routes
+// such as "/photos/search".
+func (r *Resource) Collection() *RouteGroup {
+	return r.collection
+}
+
+// Member returns the route group of a single member of the resource, which can be used to add
+// custom routes such as "/photos/<id>/download".
+func (r *Resource) Member() *RouteGroup {
+	return r.collection.Group("/<id>", r.collection.handlers...)
+}
+
+// Name returns the name of the resource, such as "photos" or "users.photos" for nested resources.
+func (r *Resource) Name() string {
+	return r.name
+}
+
+func (rg *RouteGroup) resource(prefix, path string, controller interface{}, handlers []Handler) *Resource {
+	path = "/" + strings.Trim(path, "/")
+	name := path[strings.LastIndex(path, "/")+1:]
+	r := &Resource{
+		name:       prefix + name,
+		param:      singularize(name) + "_id",
+		collection: rg.Group(path, combineHandlers(rg.handlers, handlers)...),
+	}
+
+	v := reflect.ValueOf(controller)
+	for _, a := range resourceActions {
+		method := v.MethodByName(a.action)
+		if !method.IsValid() {
+			continue
+		}
+		if h, ok := method.Interface().(func(*Context) error); ok {
+			r.collection.add(a.method, a.path, []Handler{h}).Name(r.name + "." + strings.ToLower(a.action))
+		}
+	}
+	return r
+}
+
+// singularize returns the singular form of a plural English noun for the common cases.
+func singularize(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies") && len(s) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "sses"), strings.HasSuffix(s, "xes"), strings.HasSuffix(s, "ches"), strings.HasSuffix(s, "shes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss"):
+		return s[:len(s)-1]
+	}
+	return s
+}
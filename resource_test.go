@@ -0,0 +1,111 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type photoController struct{}
+
+func (photoController) Index(c *Context) error {
+	return c.String("index")
+}
+
+func (photoController) New(c *Context) error {
+	return c.String("new")
+}
+
+func (photoController) Show(c *Context) error {
+	return c.String("show " + c.Param("id").String())
+}
+
+func (photoController) Destroy(c *Context) error {
+	return c.String("destroy " + c.Param("id").String())
+}
+
+// Edit has a signature that is not a handler, so it is ignored.
+func (photoController) Edit() {}
+
+type userController struct{}
+
+func (*userController) Show(c *Context) error {
+	return c.String("user " + c.Param("id").String())
+}
+
+type userPhotoController struct{}
+
+func (userPhotoController) Show(c *Context) error {
+	return c.String("user " + c.Param("user_id").String() + " photo " + c.Param("id").String())
+}
+
+func TestRouteGroupResource(t *testing.T) {
+	m := New()
+	var calls int
+	r := m.Group("/api").Resource("/photos/", photoController{}, func(c *Context) error {
+		calls++
+		return c.Next()
+	})
+	assert.Equal(t, "photos", r.Name())
+	assert.Len(t, m.Routes(), 4)
+	assert.Equal(t, "/api/photos/<id>", m.Route("photos.show").Path())
+	assert.Nil(t, m.Route("photos.edit"))
+	assert.Nil(t, m.Route("photos.create"))
+
+	tests := []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{"GET", "/api/photos", http.StatusOK, "index"},
+		{"GET", "/api/photos/new", http.StatusOK, "new"},
+		{"GET", "/api/photos/1", http.StatusOK, "show 1"},
+		{"DELETE", "/api/photos/2", http.StatusOK, "destroy 2"},
+		{"POST", "/api/photos", http.StatusMethodNotAllowed, ""},
+	}
+	for _, test := range tests {
+		code, body := serveString(m, test.method, test.path)
+		assert.Equal(t, test.code, code, test.method+" "+test.path)
+		assert.Equal(t, test.body, body, test.method+" "+test.path)
+	}
+	assert.Equal(t, 4, calls)
+
+	r.Member().Get("/download", func(c *Context) error {
+		return c.String("download " + c.Param("id").String())
+	})
+	_, body := serveString(m, "GET", "/api/photos/3/download")
+	assert.Equal(t, "download 3", body)
+	assert.Equal(t, 5, calls)
+}
+
+func TestResourceNested(t *testing.T) {
+	m := New()
+	users := m.Resource("/users", &userController{})
+	photos := users.Resource("/photos", userPhotoController{})
+	assert.Equal(t, "users.photos", photos.Name())
+	assert.Equal(t, "/users/<user_id>/photos/<id>", m.Route("users.photos.show").Path())
+	assert.Equal(t, "/users/1/photos/2", m.Route("users.photos.show").URL("user_id", 1, "id", 2))
+
+	_, body := serveString(m, "GET", "/users/1")
+	assert.Equal(t, "user 1", body)
+	_, body = serveString(m, "GET", "/users/1/photos/2")
+	assert.Equal(t, "user 1 photo 2", body)
+}
+
+func TestSingularize(t *testing.T) {
+	tests := map[string]string{
+		"photos":     "photo",
+		"categories": "category",
+		"boxes":      "box",
+		"addresses":  "address",
+		"branches":   "branch",
+		"glass":      "glass",
+		"sheep":      "sheep",
+	}
+	for plural, singular := range tests {
+		assert.Equal(t, singular, singularize(plural), plural)
+	}
+}
@@ -17,6 +17,10 @@ type (
 		Size      int64
 		Committed bool
 		makross   *Makross
+		hijacked  bool // whether the connection has been taken over by the handler
+
+		beforeFuncs []func()
+		afterFuncs  []func()
 	}
 )
 
@@ -45,10 +49,22 @@ func (r *Response) WriteHeader(code int) {
 		return
 	}
 	r.Status = code
-	r.Writer.WriteHeader(code)
+	r.RunBefore()
+	r.Writer.WriteHeader(r.Status)
 	r.Committed = true
 }
 
+// RunBefore calls the functions registered by Before which have not been called yet.
+// WriteHeader calls it; it must be called explicitly when the header is written by other means,
+// such as on a hijacked connection, after setting Status and the header.
+func (r *Response) RunBefore() {
+	funcs := r.beforeFuncs
+	r.beforeFuncs = nil
+	for _, fn := range funcs {
+		fn()
+	}
+}
+
 // Write writes the data to the connection as part of an HTTP reply.
 func (r *Response) Write(b []byte) (n int, err error) {
 	if !r.Committed {
@@ -59,6 +75,39 @@ func (r *Response) Write(b []byte) (n int, err error) {
 	return
 }
 
+// Before registers a function which is called just before the response header is written,
+// either explicitly by WriteHeader, implicitly by the first Write, or when the request completes
+// without anything written. The functions are called in
+// the order they are registered, with the status available in Status; they may still modify the
+// header and the status.
+func (r *Response) Before(fn func()) {
+	r.beforeFuncs = append(r.beforeFuncs, fn)
+}
+
+// After registers a function which is called after the handler chain of the request has completed
+// and any returned error has been handled, with the final Status and Size available.
+// The functions are called in the order they are registered.
+func (r *Response) After(fn func()) {
+	r.afterFuncs = append(r.afterFuncs, fn)
+}
+
+// commit writes the response header with the current status if the handlers have written nothing,
+// so that the functions registered by Before are called for every response.
+func (r *Response) commit() {
+	if !r.Committed && !r.hijacked {
+		r.WriteHeader(r.Status)
+	}
+}
+
+// runAfter calls the functions registered by After.
+func (r *Response) runAfter() {
+	funcs := r.afterFuncs
+	r.afterFuncs = nil
+	for _, fn := range funcs {
+		fn()
+	}
+}
+
 // Flush implements the http.Flusher interface to allow an HTTP handler to flush
 // buffered data to the client.
 // See [http.Flusher](https://golang.org/pkg/net/http/#Flusher)
@@ -70,7 +119,11 @@ func (r *Response) Flush() {
 // take over the connection.
 // See [http.Hijacker](https://golang.org/pkg/net/http/#Hijacker)
 func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return r.Writer.(http.Hijacker).Hijack()
+	conn, rw, err := r.Writer.(http.Hijacker).Hijack()
+	if err == nil {
+		r.hijacked = true
+	}
+	return conn, rw, err
 }
 
 // CloseNotify implements the http.CloseNotifier interface to allow detecting
@@ -87,4 +140,24 @@ func (r *Response) reset(w http.ResponseWriter) {
 	r.Size = 0
 	r.Status = StatusOK
 	r.Committed = false
+	r.hijacked = false
+	r.beforeFuncs = nil
+	r.afterFuncs = nil
+}
+
+// headResponseWriter wraps an http.ResponseWriter and discards the response body written for a HEAD request.
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+// Write discards the data but reports it as written.
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// Flush implements the http.Flusher interface if the wrapped writer does.
+func (w *headResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
 }
@@ -0,0 +1,95 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseBeforeAfter(t *testing.T) {
+	m := New()
+	var calls []string
+	var status int
+	var size int64
+	m.Use(func(c *Context) error {
+		c.Response.Before(func() {
+			calls = append(calls, "before1")
+			c.Response.Header().Set("X-Status", http.StatusText(c.Response.Status))
+		})
+		c.Response.Before(func() {
+			calls = append(calls, "before2")
+		})
+		c.Response.After(func() {
+			calls = append(calls, "after")
+			status, size = c.Response.Status, c.Response.Size
+		})
+		return c.Next()
+	})
+	m.Get("/", func(c *Context) error {
+		calls = append(calls, "handler")
+		c.Response.Write([]byte("hello"))
+		c.Response.Write([]byte(" world"))
+		return nil
+	})
+	m.Get("/empty", func(c *Context) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+	m.Get("/error", func(c *Context) error {
+		calls = append(calls, "handler")
+		return NewHTTPError(http.StatusTeapot, "teapot")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(GET, "/", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, "handler,before1,before2,after", strings.Join(calls, ","))
+	assert.Equal(t, "OK", res.Header().Get("X-Status"))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int64(11), size)
+
+	calls = nil
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest(GET, "/error", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, "handler,before1,before2,after", strings.Join(calls, ","))
+	assert.Equal(t, "I'm a teapot", res.Header().Get("X-Status"))
+	assert.Equal(t, http.StatusTeapot, status)
+	assert.Equal(t, int64(len("teapot")), size)
+
+	// the header is written when the request completes without anything written
+	calls = nil
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest(GET, "/empty", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, "handler,before1,before2,after", strings.Join(calls, ","))
+	assert.Equal(t, "OK", res.Header().Get("X-Status"))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int64(0), size)
+}
+
+func TestResponseBeforeChangesStatus(t *testing.T) {
+	m := New()
+	res := httptest.NewRecorder()
+	c := m.NewContext(httptest.NewRequest(GET, "/", nil), res)
+	c.Response.Before(func() {
+		c.Response.Status = http.StatusAccepted
+	})
+	c.Response.WriteHeader(http.StatusOK)
+	assert.Equal(t, http.StatusAccepted, res.Code)
+	assert.Equal(t, http.StatusAccepted, c.Response.Status)
+
+	// the functions are cleared when the context is reset
+	c.Response.Before(func() {
+		c.Response.Status = http.StatusAccepted
+	})
+	res = httptest.NewRecorder()
+	c.Reset(res, httptest.NewRequest(GET, "/", nil))
+	c.Response.WriteHeader(http.StatusOK)
+	assert.Equal(t, http.StatusOK, res.Code)
+}
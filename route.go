@@ -15,13 +15,17 @@ type Route struct {
 	name, template string
 	tags           []interface{}
 	routes         []*Route
+	handlers       []Handler // the handlers of the route combined with the ones of its group
+	segs           []routeSegment
 }
 
 // Name sets the name of the route.
 // This method will update the registration of the route in the makross as well.
 func (r *Route) Name(name string) *Route {
 	r.name = name
-	r.group.makross.namedRoutes[name] = r
+	r.group.withTable(func(t *routeTable, _ bool) {
+		t.namedRoutes[name] = r
+	})
 	return r
 }
 
@@ -46,6 +50,16 @@ func (r *Route) Method() string {
 	return r.method
 }
 
+// Host returns the host pattern that this route should match.
+// An empty string is returned if the route is not bound to a host.
+// For a route of a mounted makross, this is the host of the group it is mounted to.
+func (r *Route) Host() string {
+	if r.group.host != "" {
+		return r.group.host
+	}
+	return r.group.makross.mountedHost()
+}
+
 // Path returns the request path that this route should match.
 func (r *Route) Path() string {
 	return r.group.prefix + r.path
@@ -111,8 +125,19 @@ func (r *Route) To(methods string, handlers ...Handler) *Route {
 // The parameters should be given in the sequence of name1, value1, name2, value2, and so on.
 // If a parameter in the route is not provided a value, the parameter token will remain in the resulting URL.
 // The method will perform URL encoding for all given parameter values.
+// For a route bound to a host, an absolute URL using the "http" scheme is returned.
 func (r *Route) URL(pairs ...interface{}) (s string) {
-	s = r.template
+	return r.url("http", pairs...)
+}
+
+// url creates a URL using the current route and the given parameters.
+// The scheme is only used when the route is bound to a host.
+func (r *Route) url(scheme string, pairs ...interface{}) (s string) {
+	s = r.group.makross.mountTemplate() + r.template
+	host := r.Host()
+	if host != "" {
+		s = buildURLTemplate(host) + s
+	}
 	for i := 0; i < len(pairs); i++ {
 		name := fmt.Sprintf("<%v>", pairs[i])
 		value := ""
@@ -121,10 +146,13 @@ func (r *Route) URL(pairs ...interface{}) (s string) {
 		}
 		s = strings.Replace(s, name, value, -1)
 	}
+	if host != "" {
+		s = scheme + "://" + s
+	}
 	return
 }
 
 // String returns the string representation of the route.
 func (r *Route) String() string {
-	return r.method + " " + r.group.prefix + r.path
+	return r.method + " " + r.group.host + r.group.prefix + r.path
 }
@@ -4,7 +4,9 @@ package makross
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,7 +22,7 @@ func newMockStore() *mockStore {
 }
 
 func (s *mockStore) Add(key string, data interface{}) int {
-	for _, handler := range data.([]Handler) {
+	for _, handler := range data.(*Route).handlers {
 		handler(nil)
 	}
 	return s.store.Add(key, data)
@@ -205,3 +207,16 @@ POST /users/<id>/profile
 POST /admin/users
 `, s)
 }
+
+func TestRouteHostURL(t *testing.T) {
+	makross := New()
+	r := makross.Host("<tenant>.example.com").Get("/users/<id>").Name("user")
+	assert.Equal(t, "<tenant>.example.com", r.Host())
+	assert.Equal(t, "http://acme.example.com/users/1", r.URL("tenant", "acme", "id", 1))
+	assert.Equal(t, "GET <tenant>.example.com/users/<id>", r.String())
+
+	req, _ := http.NewRequest("GET", "https://acme.example.com/", nil)
+	req.TLS = &tls.ConnectionState{}
+	c := makross.NewContext(req, nil)
+	assert.Equal(t, "https://beta.example.com/users/2", c.URL("user", "tenant", "beta", "id", 2))
+}
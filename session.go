@@ -2,6 +2,7 @@ package makross
 
 import (
 	"net/url"
+	"sort"
 )
 
 type (
@@ -39,14 +40,27 @@ type (
 
 	sessioner struct{}
 
+	// Flash carries one-time messages across a redirect. Messages added for the
+	// next request are kept in Values, which the session middleware stores in the
+	// session and loads back on the following request.
 	Flash struct {
 		FlashNow bool
 		Ctx      *Context
 		url.Values
 		ErrorMsg, WarningMsg, InfoMsg, SuccessMsg string
+
+		current url.Values
 	}
 )
 
+// Built-in flash message categories.
+const (
+	FlashError   = "error"
+	FlashWarning = "warning"
+	FlashInfo    = "info"
+	FlashSuccess = "success"
+)
+
 // Set value to session
 func (s *sessioner) Set(key, value interface{}) error { return nil }
 
@@ -87,35 +101,103 @@ func (s *sessioner) GC() {}
 //  \___  /   |_______ \____|__  /_______  /\___|_  /
 //      \/            \/       \/        \/       \/
 
-func (f *Flash) set(name, msg string, current ...bool) {
-	isShow := false
+// NewFlash returns an empty Flash bound to the given context.
+func NewFlash(c *Context) *Flash {
+	return &Flash{FlashNow: FlashNow, Ctx: c, Values: url.Values{}}
+}
+
+// Load makes the given messages, usually the ones queued by the previous request
+// and read back from the session, available to the current request.
+func (f *Flash) Load(values url.Values) {
+	for category, msgs := range values {
+		for _, msg := range msgs {
+			f.show(category, msg)
+		}
+	}
+}
+
+// Add adds a message of the given category. The built-in categories are
+// FlashError, FlashWarning, FlashInfo and FlashSuccess, but any name may be used.
+// The message is shown by the next request, or by the current one if current
+// is true or, when current is omitted, if FlashNow is true.
+func (f *Flash) Add(category, msg string, current ...bool) {
 	if (len(current) == 0 && FlashNow) || (len(current) > 0 && current[0]) {
-		isShow = true
+		f.FlashNow = true
+		f.show(category, msg)
+		return
+	}
+	if f.Values == nil {
+		f.Values = url.Values{}
+	}
+	f.Values.Add(category, msg)
+}
+
+// Keep queues the messages shown by the current request again for the next one,
+// for handlers that redirect instead of rendering them.
+func (f *Flash) Keep() {
+	for category, msgs := range f.current {
+		for _, msg := range msgs {
+			f.Add(category, msg, false)
+		}
+	}
+}
+
+// Message returns the latest message of the given category shown by the current request.
+func (f *Flash) Message(category string) string {
+	msgs := f.current[category]
+	if len(msgs) == 0 {
+		return ""
+	}
+	return msgs[len(msgs)-1]
+}
+
+// Messages returns all messages of the given category shown by the current request.
+func (f *Flash) Messages(category string) []string {
+	return f.current[category]
+}
+
+// Categories returns the sorted names of the categories that have messages for the current request.
+func (f *Flash) Categories() []string {
+	categories := make([]string, 0, len(f.current))
+	for category := range f.current {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+func (f *Flash) show(category, msg string) {
+	if f.current == nil {
+		f.current = url.Values{}
+	}
+	f.current.Add(category, msg)
+	switch category {
+	case FlashError:
+		f.ErrorMsg = msg
+	case FlashWarning:
+		f.WarningMsg = msg
+	case FlashInfo:
+		f.InfoMsg = msg
+	case FlashSuccess:
+		f.SuccessMsg = msg
 	}
-	f.FlashNow = isShow
-	if isShow {
+	if f.Ctx != nil {
 		f.Ctx.Set("Flash", f)
-	} else {
-		f.Set(name, msg)
 	}
 }
 
 func (f *Flash) Error(msg string, current ...bool) {
-	f.ErrorMsg = msg
-	f.set("error", msg, current...)
+	f.Add(FlashError, msg, current...)
 }
 
 func (f *Flash) Warning(msg string, current ...bool) {
-	f.WarningMsg = msg
-	f.set("warning", msg, current...)
+	f.Add(FlashWarning, msg, current...)
 }
 
 func (f *Flash) Info(msg string, current ...bool) {
-	f.InfoMsg = msg
-	f.set("info", msg, current...)
+	f.Add(FlashInfo, msg, current...)
 }
 
 func (f *Flash) Success(msg string, current ...bool) {
-	f.SuccessMsg = msg
-	f.set("success", msg, current...)
+	f.Add(FlashSuccess, msg, current...)
 }
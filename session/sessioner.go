@@ -43,6 +43,32 @@ type Store interface {
 type store struct {
 	makross.RawStore
 	*Manager
+	saved *bool
+}
+
+// Set sets value to the given key in session.
+func (s store) Set(key, value interface{}) error {
+	s.changed()
+	return s.RawStore.Set(key, value)
+}
+
+// Delete deletes a key from session.
+func (s store) Delete(key interface{}) error {
+	s.changed()
+	return s.RawStore.Delete(key)
+}
+
+// Flush deletes all session data.
+func (s store) Flush() error {
+	s.changed()
+	return s.RawStore.Flush()
+}
+
+// changed marks the session to be saved again at the end of the request.
+func (s store) changed() {
+	if s.saved != nil {
+		*s.saved = false
+	}
 }
 
 var _ Store = &store{}
@@ -97,53 +123,34 @@ func Sessioner(op ...Options) makross.Handler {
 			return err
 		}
 
+		saved, flashed := false, ""
 		c.Session = store{
 			RawStore: sess,
 			Manager:  GlobalManager,
+			saved:    &saved,
 		}
 
-		var has bool
-		flashVals := url.Values{}
-		flashIf := c.Session.Get(SESSION_FLASH_KEY)
-		if flashIf != nil {
-			//vals, _ := url.QueryUnescape(flashIf.(string))
-			if flasho, okay := flashIf.(*makross.Flash); okay {
-				if flashVals, _ = url.ParseQuery(flasho.Encode()); len(flashVals) > 0 {
-					flash := makross.Flash{Values: url.Values{}}
-					flash.ErrorMsg = flashVals.Get("error")
-					flash.WarningMsg = flashVals.Get("warning")
-					flash.InfoMsg = flashVals.Get("info")
-					flash.SuccessMsg = flashVals.Get("success")
-
-					flash.Ctx = c
-					if flasho.FlashNow {
-						flash.FlashNow = true
-						c.Set(CONTEXT_FLASH_KEY, flash)
-					} else {
-						flash.FlashNow = false
-						flash.Ctx.Set(CONTEXT_FLASH_KEY, flash)
-					}
-					c.Flash = &flash
-					has = true
-
-				}
-			}
-
-		}
-
-		if !has {
-			c.Flash = NewFlash(new(makross.Context))
-			c.Set(CONTEXT_FLASH_KEY, c.Flash)
+		flash := makross.NewFlash(c)
+		if values, okay := c.Session.Get(SESSION_FLASH_KEY).(url.Values); okay {
+			flash.Load(values)
 		}
-
+		c.Flash = flash
+		c.Set(CONTEXT_FLASH_KEY, flash)
 		c.Set(CONTEXT_SESSION_KEY, c.Session)
 
-		defer func() {
-			//log.Println("save session", sess)
-			//sess.Set(SESSION_FLASH_KEY, url.QueryEscape(f.Encode()))
-			c.Session.Set(SESSION_FLASH_KEY, c.Flash)
+		// The session is saved before the response headers are written, so that cookie
+		// based providers can still set their cookie, and again once the request is
+		// complete if it has changed since, e.g. after the first write of the handler.
+		save := func() {
+			if saved && flash.Values.Encode() == flashed {
+				return
+			}
+			saveFlash(c.Session, flash)
 			c.Session.Release(c)
-		}()
+			saved, flashed = true, flash.Values.Encode()
+		}
+		c.Response.Before(save)
+		defer save()
 		return c.Next()
 	}
 }
@@ -158,24 +165,34 @@ func GetStore(c *makross.Context) Store {
 	return nil
 }
 
+// saveFlash stores the messages queued for the next request in the session,
+// or clears the ones that have just been shown.
+func saveFlash(s makross.RawStore, flash *makross.Flash) {
+	if len(flash.Values) > 0 {
+		s.Set(SESSION_FLASH_KEY, flash.Values)
+	} else {
+		s.Delete(SESSION_FLASH_KEY)
+	}
+}
+
+// GetFlash returns the Flash of the current request.
 func GetFlash(c *makross.Context) *makross.Flash {
-	if store := GetStore(c); store != nil {
-		if tmp := store.Get(SESSION_FLASH_KEY); tmp != nil {
-			if flash, okay := tmp.(*makross.Flash); okay {
-				return flash
-			}
-		}
+	if c.Flash != nil {
+		return c.Flash
+	}
+	if flash, okay := c.Get(CONTEXT_FLASH_KEY).(*makross.Flash); okay {
+		return flash
 	}
 	return NewFlash(c)
 }
 
 func FlashValue(c *makross.Context) makross.Flash {
-	if tmp := c.Get(CONTEXT_FLASH_KEY); tmp != nil {
-		return tmp.(makross.Flash)
+	if flash := GetFlash(c); flash != nil {
+		return *flash
 	}
 	return makross.Flash{}
 }
 
 func NewFlash(ctx *makross.Context) *makross.Flash {
-	return &makross.Flash{makross.FlashNow, ctx, url.Values{}, "", "", "", ""}
+	return makross.NewFlash(ctx)
 }
@@ -0,0 +1,111 @@
+package session
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/insionng/makross"
+)
+
+func TestSessionerFlash(t *testing.T) {
+	m := makross.New()
+	m.Use(Sessioner())
+	m.Post("/save", func(c *makross.Context) error {
+		c.Flash.Success("saved")
+		c.Flash.Add("notice", "check your inbox")
+		return c.Redirect("/show", http.StatusSeeOther)
+	})
+	m.Get("/show", func(c *makross.Context) error {
+		flash := GetFlash(c)
+		return c.String(flash.SuccessMsg + "|" + strings.Join(flash.Messages("notice"), ","))
+	})
+
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("POST", "/save", nil))
+	if res.Code != http.StatusSeeOther {
+		t.Fatalf("unexpected status %d", res.Code)
+	}
+	cookies := res.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("session cookie not set")
+	}
+
+	show := func() string {
+		req := httptest.NewRequest("GET", "/show", nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		res := httptest.NewRecorder()
+		m.ServeHTTP(res, req)
+		return res.Body.String()
+	}
+	if body := show(); body != "saved|check your inbox" {
+		t.Errorf("first request got %q", body)
+	}
+	if body := show(); body != "|" {
+		t.Errorf("flash not cleared, second request got %q", body)
+	}
+
+	// the session is saved when the handler writes nothing
+	m.Get("/quiet", func(c *makross.Context) error {
+		c.Flash.Success("quietly saved")
+		return nil
+	})
+	req := httptest.NewRequest("GET", "/quiet", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	m.ServeHTTP(httptest.NewRecorder(), req)
+	if body := show(); body != "quietly saved|" {
+		t.Errorf("flash of a request without response body not saved, got %q", body)
+	}
+}
+
+func TestSessionerSaveAfterWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "makross-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	manager, err := NewManager("file", `{"cookieName":"makrossSessionId","gcLifetime":3600,"providerConfig":"`+dir+`"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := makross.New()
+	m.Use(Sessioner())
+	defer func(previous *Manager) { GlobalManager = previous }(GlobalManager)
+	GlobalManager = manager
+
+	m.Get("/write", func(c *makross.Context) error {
+		if err := c.String("written"); err != nil {
+			return err
+		}
+		return c.Session.Set("user", "makross")
+	})
+	m.Get("/read", func(c *makross.Context) error {
+		user, _ := c.Session.Get("user").(string)
+		return c.String(user)
+	})
+
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/write", nil))
+	cookies := res.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("session cookie not set")
+	}
+
+	req := httptest.NewRequest("GET", "/read", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	res = httptest.NewRecorder()
+	m.ServeHTTP(res, req)
+	if body := res.Body.String(); body != "makross" {
+		t.Errorf("session value set after the response was written not saved, got %q", body)
+	}
+}
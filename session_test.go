@@ -0,0 +1,42 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlash(t *testing.T) {
+	c := &Context{}
+	f := NewFlash(c)
+
+	f.Error("next")
+	f.Add("notice", "queued")
+	assert.Equal(t, url.Values{"error": {"next"}, "notice": {"queued"}}, f.Values)
+	assert.Equal(t, "", f.ErrorMsg)
+	assert.Empty(t, f.Categories())
+	assert.Nil(t, c.Get("Flash"))
+
+	f.Success("now", true)
+	f.Add("notice", "first", true)
+	f.Add("notice", "second", true)
+	assert.Equal(t, "now", f.SuccessMsg)
+	assert.Equal(t, "second", f.Message("notice"))
+	assert.Equal(t, []string{"first", "second"}, f.Messages("notice"))
+	assert.Equal(t, []string{"notice", "success"}, f.Categories())
+	assert.Equal(t, "", f.Message("missing"))
+	assert.Equal(t, f, c.Get("Flash"))
+	assert.Len(t, f.Values, 2)
+
+	next := NewFlash(&Context{})
+	next.Load(f.Values)
+	assert.Equal(t, "next", next.ErrorMsg)
+	assert.Equal(t, []string{"queued"}, next.Messages("notice"))
+	assert.Empty(t, next.Values)
+
+	next.Keep()
+	assert.Equal(t, url.Values{"error": {"next"}, "notice": {"queued"}}, next.Values)
+}
@@ -0,0 +1,193 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Event represents a server-sent event.
+	Event struct {
+		ID    string        // the event ID, which is sent back by the client in Last-Event-ID when it reconnects
+		Event string        // the event type, "message" is assumed by the client if empty
+		Data  interface{}   // the event data; strings and byte slices are sent as is, other values in JSON format
+		Retry time.Duration // the reconnection time the client should use, ignored if zero
+	}
+
+	// EventStream writes server-sent events to the response. It is safe for concurrent use.
+	EventStream struct {
+		c       *Context
+		mu      sync.Mutex
+		done    chan struct{}
+		watched chan struct{} // closed when the goroutine watching the stream has returned
+		closed  bool
+	}
+)
+
+// SSE starts a stream of server-sent events by sending the "text/event-stream" response headers.
+// If a heartbeat interval is given, a comment is sent at that interval to keep the connection alive.
+// The stream is closed when the client disconnects, and at the latest when the request completes,
+// as the context is reused afterwards. Handlers should close it with EventStream.Close before returning:
+//
+//	s, err := c.SSE(15 * time.Second)
+//	if err != nil {
+//		return err
+//	}
+//	defer s.Close()
+//	for {
+//		select {
+//		case <-s.Done():
+//			return nil
+//		case p := <-progress:
+//			s.Send(makross.Event{ID: p.ID, Event: "progress", Data: p})
+//		}
+//	}
+func (c *Context) SSE(heartbeat ...time.Duration) (*EventStream, error) {
+	if _, ok := c.Response.Writer.(http.Flusher); !ok {
+		return nil, ErrStreamingUnsupported
+	}
+	header := c.Response.Header()
+	header.Set(HeaderContentType, MIMETextEventStream)
+	header.Set(HeaderCacheControl, "no-cache")
+	header.Set("X-Accel-Buffering", "no") // disables the response buffering of nginx
+	c.Response.WriteHeader(StatusOK)
+	c.Response.Flush()
+
+	s := &EventStream{c: c, done: make(chan struct{}), watched: make(chan struct{})}
+	var interval time.Duration
+	if len(heartbeat) > 0 {
+		interval = heartbeat[0]
+	}
+	go s.watch(interval, c.Request.Context().Done())
+	c.Response.After(func() {
+		s.Close()
+		<-s.watched
+	})
+	return s, nil
+}
+
+// LastEventID returns the ID of the last event received by the client before it reconnected,
+// which can be used to resume the stream. It returns an empty string for new connections.
+func (s *EventStream) LastEventID() string {
+	return s.c.Request.Header.Get(HeaderLastEventID)
+}
+
+// Send sends an event to the client.
+// ErrStreamClosed is returned if the stream has been closed, e.g. when the client has disconnected.
+func (s *EventStream) Send(e Event) error {
+	var buf bytes.Buffer
+	if e.ID != "" {
+		writeEventField(&buf, "id", strings.NewReplacer("\r", "", "\n", "").Replace(e.ID))
+	}
+	if e.Event != "" {
+		writeEventField(&buf, "event", strings.NewReplacer("\r", "", "\n", "").Replace(e.Event))
+	}
+	if e.Retry > 0 {
+		writeEventField(&buf, "retry", strconv.FormatInt(int64(e.Retry/time.Millisecond), 10))
+	}
+	if e.Data != nil {
+		var data string
+		switch d := e.Data.(type) {
+		case string:
+			data = d
+		case []byte:
+			data = string(d)
+		default:
+			b, err := json.Marshal(d)
+			if err != nil {
+				return err
+			}
+			data = string(b)
+		}
+		data = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(data)
+		for _, line := range strings.Split(data, "\n") {
+			writeEventField(&buf, "data", line)
+		}
+	}
+	buf.WriteByte('\n')
+	return s.write(buf.Bytes())
+}
+
+// Data sends an event with the given data and no ID or type.
+func (s *EventStream) Data(data interface{}) error {
+	return s.Send(Event{Data: data})
+}
+
+// Comment sends a comment, which is ignored by the client.
+func (s *EventStream) Comment(text string) error {
+	var buf bytes.Buffer
+	for _, line := range strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text), "\n") {
+		writeEventField(&buf, "", line)
+	}
+	buf.WriteByte('\n')
+	return s.write(buf.Bytes())
+}
+
+// Done returns a channel that is closed when the stream is closed.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close closes the stream and stops sending heartbeats. Nothing is sent after the stream is closed.
+func (s *EventStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.close()
+}
+
+func (s *EventStream) close() {
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+}
+
+func (s *EventStream) write(b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStreamClosed
+	}
+	if _, err := s.c.Response.Write(b); err != nil {
+		s.close()
+		return err
+	}
+	s.c.Response.Flush()
+	return nil
+}
+
+// watch sends heartbeats and closes the stream when the client disconnects.
+func (s *EventStream) watch(heartbeat time.Duration, disconnected <-chan struct{}) {
+	defer close(s.watched)
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-disconnected:
+			s.Close()
+			return
+		case <-tick:
+			s.Comment("heartbeat")
+		}
+	}
+}
+
+func writeEventField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
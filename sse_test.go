@@ -0,0 +1,113 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type noFlushWriter struct {
+	http.ResponseWriter
+}
+
+func newSSEContext(r *http.Request, w http.ResponseWriter) *Context {
+	m := New()
+	c := m.AcquireContext()
+	c.Reset(w, r)
+	return c
+}
+
+func TestContextSSE(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/events", nil)
+	req.Header.Set(HeaderLastEventID, "41")
+	res := httptest.NewRecorder()
+	c := newSSEContext(req, res)
+
+	s, err := c.SSE()
+	if assert.Nil(t, err) {
+		assert.Equal(t, "41", s.LastEventID())
+		assert.Nil(t, s.Send(Event{ID: "42", Event: "progress", Data: map[string]int{"done": 10}, Retry: 3 * time.Second}))
+		assert.Nil(t, s.Data("line1\nline2"))
+		assert.Nil(t, s.Comment("ping"))
+		s.Close()
+		assert.Equal(t, ErrStreamClosed, s.Data("after close"))
+		<-s.Done()
+	}
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, MIMETextEventStream, res.Header().Get(HeaderContentType))
+	assert.Equal(t, "no-cache", res.Header().Get(HeaderCacheControl))
+	assert.Equal(t, "id: 42\nevent: progress\nretry: 3000\ndata: {\"done\":10}\n\n"+
+		"data: line1\ndata: line2\n\n"+
+		": ping\n\n", res.Body.String())
+}
+
+func TestContextSSEHeartbeat(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/events", nil)
+	res := httptest.NewRecorder()
+	c := newSSEContext(req, res)
+
+	s, err := c.SSE(time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(20 * time.Millisecond)
+	s.Close()
+	assert.True(t, strings.HasPrefix(res.Body.String(), ": heartbeat\n\n"))
+}
+
+func TestContextSSEDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", "/events", nil)
+	req = req.WithContext(ctx)
+	c := newSSEContext(req, httptest.NewRecorder())
+
+	s, err := c.SSE()
+	assert.Nil(t, err)
+	cancel()
+	select {
+	case <-s.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the stream is not closed after the client disconnects")
+	}
+	assert.Equal(t, ErrStreamClosed, s.Data("data"))
+}
+
+func TestContextSSEHandlerReturns(t *testing.T) {
+	m := New()
+	var s *EventStream
+	m.Get("/events", func(c *Context) error {
+		var err error
+		// the handler returns without closing the stream
+		s, err = c.SSE(time.Millisecond)
+		return err
+	})
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/events", nil))
+	select {
+	case <-s.Done():
+	default:
+		t.Fatal("the stream is not closed after the handler returns")
+	}
+	select {
+	case <-s.watched:
+	default:
+		t.Fatal("the stream is still watched after the handler returns")
+	}
+	body := res.Body.String()
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, ErrStreamClosed, s.Data("late"))
+	assert.Equal(t, body, res.Body.String())
+}
+
+func TestContextSSEUnsupported(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/events", nil)
+	c := newSSEContext(req, noFlushWriter{httptest.NewRecorder()})
+	_, err := c.SSE()
+	assert.Equal(t, ErrStreamingUnsupported, err)
+}
@@ -40,9 +40,10 @@ func (s *store) Add(key string, data interface{}) int {
 
 // Get returns the data item matching the given concrete key.
 // If the data item was added to the store with a parametric key before, the matching
-// parameter names and values will be returned as well.
-func (s *store) Get(path string, pvalues []string) (data interface{}, pnames []string) {
-	data, pnames, _ = s.root.get(path, pvalues)
+// parameter names and values will be returned as well. The values of the typed parameters,
+// converted while matching them, are stored in ptyped unless it is nil.
+func (s *store) Get(path string, pvalues []string, ptyped []interface{}) (data interface{}, pnames []string) {
+	data, pnames, _ = s.root.get(path, pvalues, ptyped)
 	return
 }
 
@@ -65,6 +66,7 @@ type node struct {
 	pchildren []*node // child param nodes
 
 	regex  *regexp.Regexp // regular expression for a param node containing regular expression key
+	ptype  *ParamType     // the parameter type for a param node whose pattern is a registered type name
 	pindex int            // the parameter index, meaningful only for param node
 	pnames []string       // the parameter names collected from the root till this node
 }
@@ -195,7 +197,11 @@ func (n *node) addChild(key string, data interface{}, order int) int {
 			break
 		}
 	}
-	if pattern != "" {
+	if t := paramTypes[pattern]; t != nil {
+		// the param token refers to a registered parameter type
+		child.ptype = t
+		child.regex = regexp.MustCompile("^(?:" + t.Pattern + ")")
+	} else if pattern != "" {
 		// the param token contains a regular expression
 		child.regex = regexp.MustCompile("^" + pattern)
 	}
@@ -218,7 +224,7 @@ func (n *node) addChild(key string, data interface{}, order int) int {
 }
 
 // get returns the data item with the key matching the tree rooted at the current node
-func (n *node) get(key string, pvalues []string) (data interface{}, pnames []string, order int) {
+func (n *node) get(key string, pvalues []string, ptyped []interface{}) (data interface{}, pnames []string, order int) {
 	order = math.MaxInt32
 
 repeat:
@@ -239,9 +245,19 @@ repeat:
 		// param node with regular expression
 		if n.regex.String() == "^.*" {
 			pvalues[n.pindex] = key
+			n.setTyped(ptyped, nil)
 			key = ""
 		} else if match := n.regex.FindStringIndex(key); match != nil {
+			var value interface{}
+			if n.ptype != nil && n.ptype.Convert != nil {
+				// the value must be convertible to the parameter type
+				var err error
+				if value, err = n.ptype.Convert(key[0:match[1]]); err != nil {
+					return
+				}
+			}
 			pvalues[n.pindex] = key[0:match[1]]
+			n.setTyped(ptyped, value)
 			key = key[match[1]:]
 		} else {
 			return
@@ -252,12 +268,14 @@ repeat:
 		for ; i < kl; i++ {
 			if key[i] == '/' {
 				pvalues[n.pindex] = key[0:i]
+				n.setTyped(ptyped, nil)
 				key = key[i:]
 				break
 			}
 		}
 		if i == kl {
 			pvalues[n.pindex] = key
+			n.setTyped(ptyped, nil)
 			key = ""
 		}
 	}
@@ -270,7 +288,7 @@ repeat:
 				n = child
 				goto repeat
 			}
-			data, pnames, order = child.get(key, pvalues)
+			data, pnames, order = child.get(key, pvalues, ptyped)
 		}
 	} else if n.data != nil {
 		// do not return yet: a param node may match an empty string with smaller order
@@ -278,7 +296,7 @@ repeat:
 	}
 
 	// try matching param children
-	tvalues := pvalues
+	tvalues, ttyped := pvalues, ptyped
 	allocated := false
 	for _, child := range n.pchildren {
 		if child.minOrder >= order {
@@ -286,13 +304,19 @@ repeat:
 		}
 		if data != nil && !allocated {
 			tvalues = make([]string, len(pvalues))
+			if ptyped != nil {
+				ttyped = make([]interface{}, len(ptyped))
+			}
 			allocated = true
 		}
-		if d, p, s := child.get(key, tvalues); d != nil && s < order {
+		if d, p, s := child.get(key, tvalues, ttyped); d != nil && s < order {
 			if allocated {
 				for i := child.pindex; i < len(p); i++ {
 					pvalues[i] = tvalues[i]
 				}
+				if ptyped != nil {
+					copy(ptyped[child.pindex:len(p)], ttyped[child.pindex:len(p)])
+				}
 			}
 			data, pnames, order = d, p, s
 		}
@@ -301,6 +325,13 @@ repeat:
 	return
 }
 
+// setTyped stores the converted value of the parameter matched by the node in ptyped, unless it is nil.
+func (n *node) setTyped(ptyped []interface{}, value interface{}) {
+	if ptyped != nil {
+		ptyped[n.pindex] = value
+	}
+}
+
 func (n *node) print(level int) string {
 	r := fmt.Sprintf("%v{key: %v, regex: %v, data: %v, order: %v, minOrder: %v, pindex: %v, pnames: %v}\n", strings.Repeat(" ", level<<2), n.key, n.regex, n.data, n.order, n.minOrder, n.pindex, n.pnames)
 	for _, child := range n.children {
@@ -149,7 +149,7 @@ func TestStoreGet(t *testing.T) {
 	}
 	pvalues := make([]string, maxParams)
 	for _, test := range tests {
-		data, pnames := h.Get(test.key, pvalues)
+		data, pnames := h.Get(test.key, pvalues, nil)
 		assert.Equal(t, test.value, data, "store.Get("+test.key+") =")
 		params := ""
 		if len(pnames) > 0 {
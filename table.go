@@ -0,0 +1,241 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"strings"
+	"sync"
+)
+
+// routeTable holds the routes of a makross and the stores used to dispatch requests to them.
+// A table published for dispatching is never modified by Makross.Update, which applies
+// its changes to a copy and then swaps the copy in.
+type routeTable struct {
+	routes      []*Route
+	namedRoutes map[string]*Route
+	stores      map[string]routeStore
+	hostStores  map[string]routeStore // stores for the routes bound to a host pattern, keyed by method
+	maxParams   int
+}
+
+func newRouteTable() *routeTable {
+	return &routeTable{
+		namedRoutes: make(map[string]*Route),
+		stores:      make(map[string]routeStore),
+		hostStores:  make(map[string]routeStore),
+	}
+}
+
+// clone returns a copy of the table with the same routes and empty stores.
+func (t *routeTable) clone() *routeTable {
+	c := newRouteTable()
+	c.routes = make([]*Route, len(t.routes))
+	copy(c.routes, t.routes)
+	for name, route := range t.namedRoutes {
+		c.namedRoutes[name] = route
+	}
+	return c
+}
+
+// rebuild fills the stores of the table with its routes.
+func (t *routeTable) rebuild() {
+	t.stores = make(map[string]routeStore)
+	t.hostStores = make(map[string]routeStore)
+	t.maxParams = 0
+	for _, route := range t.routes {
+		t.insert(route)
+	}
+}
+
+// insert adds the route to the store of its method.
+func (t *routeTable) insert(route *Route) {
+	stores := t.stores
+	if route.group.host != "" {
+		stores = t.hostStores
+	}
+
+	store := stores[route.method]
+	if store == nil {
+		store = newStore()
+		stores[route.method] = store
+	}
+
+	if n := store.Add(route.storeKey(), route); n > t.maxParams {
+		t.maxParams = n
+	}
+}
+
+// lookup returns the route and parameter names matching the given method, host and path.
+// Routes bound to a host pattern take precedence over the ones that are not.
+func (t *routeTable) lookup(method, host, path string, pvalues []string, ptyped []interface{}) (route *Route, pnames []string) {
+	var data interface{}
+	if store := t.hostStores[method]; store != nil && host != "" {
+		data, pnames = store.Get(host+path, pvalues, ptyped)
+	}
+	if data == nil {
+		if store := t.stores[method]; store != nil {
+			data, pnames = store.Get(path, pvalues, ptyped)
+		}
+	}
+	if data != nil {
+		return data.(*Route), pnames
+	}
+	return nil, pnames
+}
+
+// allowedMethods returns the methods of the routes matching the given host and path.
+func (t *routeTable) allowedMethods(host, path string) map[string]bool {
+	methods := make(map[string]bool)
+	pvalues := make([]string, t.maxParams)
+	if host != "" {
+		for m, store := range t.hostStores {
+			if route, _ := store.Get(host+path, pvalues, nil); route != nil {
+				methods[m] = true
+			}
+		}
+	}
+	for m, store := range t.stores {
+		if route, _ := store.Get(path, pvalues, nil); route != nil {
+			methods[m] = true
+		}
+	}
+	return methods
+}
+
+// storeKey returns the pattern under which the route is kept in its store.
+func (r *Route) storeKey() string {
+	path := r.group.prefix + r.path
+	if r.group.host != "" {
+		// host-bound routes are keyed by the host pattern followed by the path
+		path = buildHostPattern(r.group.host) + path
+	}
+	// an asterisk at the end matches any number of characters
+	if strings.HasSuffix(path, "*") {
+		path = path[:len(path)-1] + "<:.*>"
+	}
+	return path
+}
+
+// currentTable returns the routing table used to dispatch requests.
+func (m *Makross) currentTable() *routeTable {
+	return m.table.Load().(*routeTable)
+}
+
+// RouteUpdate is passed to the function run by Makross.Update. It is the root route group of the makross,
+// and the routes added through it and its subgroups, or removed by RemoveRoute, are applied to the
+// routing table being built by the update.
+type RouteUpdate struct {
+	RouteGroup
+	mu    sync.Mutex  // guards table
+	table *routeTable // the routing table being built, nil once the update is complete
+}
+
+// Update changes the routes of the makross while it is serving requests.
+// The routes added and removed by fn through u are applied to a copy of the routing table which atomically
+// replaces the current one once fn returns. Requests being served keep using the table they started with.
+// If fn panics, the current table is left unchanged. Updates are serialized: while one is in progress, the routes
+// added or removed through the makross or the route groups created outside fn are applied to its table as well,
+// and fn must not call Update, which would wait for the update to complete.
+//
+// Routes added outside of Update modify the current table in place and must be added
+// before the makross starts serving requests.
+func (m *Makross) Update(fn func(u *RouteUpdate)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	handlers := make([]Handler, len(m.handlers))
+	copy(handlers, m.handlers)
+	u := &RouteUpdate{table: m.routeTable.clone()}
+	u.RouteGroup = *newRouteGroup("", m, handlers)
+	u.RouteGroup.parent = &m.RouteGroup
+	u.RouteGroup.update = u
+	m.updating.Store(u)
+	defer func() {
+		// the groups of the update add their routes to the current table from now on
+		u.mu.Lock()
+		u.table = nil
+		u.mu.Unlock()
+		m.updating.Store((*RouteUpdate)(nil))
+	}()
+
+	fn(u)
+	u.apply(func(t *routeTable) {
+		t.rebuild()
+		m.routeTable = t
+		m.table.Store(t)
+	})
+}
+
+// pendingUpdate returns the update in progress, or nil if there is none.
+func (m *Makross) pendingUpdate() *RouteUpdate {
+	u, _ := m.updating.Load().(*RouteUpdate)
+	return u
+}
+
+// apply calls fn with the routing table being built and reports whether the update was still in progress.
+func (u *RouteUpdate) apply(fn func(t *routeTable)) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.table == nil {
+		return false
+	}
+	fn(u.table)
+	return true
+}
+
+// With returns a copy of the given route group, such as one created before the update,
+// whose routes are added by the update.
+func (u *RouteUpdate) With(rg *RouteGroup) *RouteGroup {
+	g := *rg
+	g.parent = rg
+	g.errorHandler = nil
+	g.update = u
+	return &g
+}
+
+// RemoveRoute removes the route with the given name from the routing table being built and reports whether it was found.
+// If the route was created by To, all of the routes it created are removed.
+func (u *RouteUpdate) RemoveRoute(name string) bool {
+	var removed bool
+	if u.apply(func(t *routeTable) { removed = t.remove(name) }) {
+		return removed
+	}
+	return u.makross.RemoveRoute(name)
+}
+
+// RemoveRoute removes the route with the given name and reports whether it was found.
+// If the route was created by To, all of the routes it created are removed.
+// The removal is applied as an update of its own, or by the update in progress;
+// use RouteUpdate.RemoveRoute to replace a route atomically.
+func (m *Makross) RemoveRoute(name string) bool {
+	var removed bool
+	if u := m.pendingUpdate(); u != nil && u.apply(func(t *routeTable) { removed = t.remove(name) }) {
+		return removed
+	}
+	m.Update(func(u *RouteUpdate) {
+		removed = u.RemoveRoute(name)
+	})
+	return removed
+}
+
+// remove removes the named route from the table, whose stores must be rebuilt afterwards.
+func (t *routeTable) remove(name string) bool {
+	route := t.namedRoutes[name]
+	if route == nil {
+		return false
+	}
+	delete(t.namedRoutes, name)
+
+	removed := map[*Route]bool{route: true}
+	for _, r := range route.routes {
+		removed[r] = true
+	}
+	routes := t.routes[:0]
+	for _, r := range t.routes {
+		if !removed[r] {
+			routes = append(routes, r)
+		}
+	}
+	t.routes = routes
+	return true
+}
@@ -0,0 +1,210 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serveString(m *Makross, method, path string) (int, string) {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	m.ServeHTTP(res, req)
+	return res.Code, res.Body.String()
+}
+
+func TestMakrossUpdate(t *testing.T) {
+	m := New()
+	m.Get("/users", func(c *Context) error {
+		return c.String("users")
+	}).Name("users")
+
+	old := m.currentTable()
+	m.Update(func(u *RouteUpdate) {
+		u.Get("/users/<id:int>/<tab>", func(c *Context) error {
+			return c.String("user " + c.Param("id").String() + " " + c.Param("tab").String())
+		})
+	})
+	assert.Len(t, old.routes, 1, "the published table is not modified")
+	assert.Len(t, m.Routes(), 2)
+	assert.Equal(t, 2, m.currentTable().maxParams)
+
+	code, body := serveString(m, "GET", "/users/1/photos")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "user 1 photos", body)
+
+	// replace a route atomically
+	m.Update(func(u *RouteUpdate) {
+		assert.True(t, u.RemoveRoute("users"))
+		u.Get("/users", func(c *Context) error {
+			return c.String("new users")
+		}).Name("users")
+	})
+	_, body = serveString(m, "GET", "/users")
+	assert.Equal(t, "new users", body)
+	assert.Len(t, m.Routes(), 2)
+
+	assert.True(t, m.RemoveRoute("users"))
+	assert.False(t, m.RemoveRoute("users"))
+	assert.Nil(t, m.Route("users"))
+	code, _ = serveString(m, "GET", "/users")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// a panicking update leaves the routes unchanged
+	assert.Panics(t, func() {
+		m.Update(func(u *RouteUpdate) {
+			u.Get("/panic")
+			panic("failed")
+		})
+	})
+	assert.Len(t, m.Routes(), 1)
+	m.Get("/users", func(c *Context) error {
+		return c.String("users")
+	})
+	assert.Len(t, m.Routes(), 2)
+
+	// groups created before the update
+	api := m.Group("/api", func(c *Context) error {
+		c.Response.Header().Set("X-API", "1")
+		return c.Next()
+	})
+	m.Update(func(u *RouteUpdate) {
+		u.With(api).Group("/v1").Get("/ping", func(c *Context) error {
+			return c.String("pong")
+		}).Name("ping")
+	})
+	assert.Equal(t, "/api/v1/ping", m.Route("ping").URL())
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/api/v1/ping", nil))
+	assert.Equal(t, "pong", res.Body.String())
+	assert.Equal(t, "1", res.Header().Get("X-API"))
+}
+
+func TestMakrossRemoveCompositeRoute(t *testing.T) {
+	m := New()
+	m.To("GET,POST", "/items", func(c *Context) error {
+		return c.String("items")
+	}).Name("items")
+	m.Get("/other", func(c *Context) error {
+		return c.String("other")
+	})
+
+	assert.True(t, m.RemoveRoute("items"))
+	assert.Len(t, m.Routes(), 1)
+	code, _ := serveString(m, "POST", "/items")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = serveString(m, "GET", "/other")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestMakrossUpdateConcurrent(t *testing.T) {
+	m := New()
+	m.Get("/ping", func(c *Context) error {
+		return c.String("pong")
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				code, body := serveString(m, "GET", "/ping")
+				assert.Equal(t, http.StatusOK, code)
+				assert.Equal(t, "pong", body)
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		m.Update(func(u *RouteUpdate) {
+			u.Get("/plugin", func(c *Context) error {
+				return c.String("plugin")
+			}).Name("plugin")
+		})
+		m.RemoveRoute("plugin")
+	}
+	wg.Wait()
+
+	// concurrent updates and removals are serialized
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := "route" + strconv.Itoa(i)
+			for j := 0; j < 20; j++ {
+				m.Update(func(u *RouteUpdate) {
+					u.Get("/"+name, func(c *Context) error {
+						return c.String(name)
+					}).Name(name)
+				})
+				assert.True(t, m.RemoveRoute(name))
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, m.Routes(), 1)
+}
+
+func TestMakrossUpdateReentrant(t *testing.T) {
+	m := New()
+	api := m.Group("/api")
+	m.Get("/old", func(c *Context) error {
+		return c.String("old")
+	}).Name("old")
+
+	// the changes made through the makross and the groups created beforehand join the update
+	m.Update(func(u *RouteUpdate) {
+		m.Get("/new", func(c *Context) error {
+			return c.String("new")
+		}).Name("new")
+		api.Get("/users", func(c *Context) error {
+			return c.String("users")
+		})
+		assert.True(t, m.RemoveRoute("old"))
+
+		code, _ := serveString(m, "GET", "/new")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	code, body := serveString(m, "GET", "/new")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "new", body)
+	code, body = serveString(m, "GET", "/api/users")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "users", body)
+	code, _ = serveString(m, "GET", "/old")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.NotNil(t, m.Route("new"))
+
+	// routes added concurrently with the updates are kept
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			m.Get("/added"+strconv.Itoa(i), func(c *Context) error {
+				return c.String("added")
+			})
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		m.Update(func(u *RouteUpdate) {
+			u.Get("/updated"+strconv.Itoa(i), func(c *Context) error {
+				return c.String("updated")
+			})
+		})
+	}
+	wg.Wait()
+	for i := 0; i < 20; i++ {
+		code, _ = serveString(m, "GET", "/added"+strconv.Itoa(i))
+		assert.Equal(t, http.StatusOK, code)
+		code, _ = serveString(m, "GET", "/updated"+strconv.Itoa(i))
+		assert.Equal(t, http.StatusOK, code)
+	}
+}
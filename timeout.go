@@ -0,0 +1,169 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	ktx "context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout returns a handler that limits the time taken by the handlers following it.
+// It sets a deadline on the context returned by Context.Kontext, which the handlers should watch
+// to stop their work. If they have not started writing the response when the deadline expires,
+// the error response with the given status (StatusServiceUnavailable by default, StatusGatewayTimeout
+// is also common) is written via Makross.HandleError, and the writes made by the handlers afterwards
+// fail with http.ErrHandlerTimeout. Timeout can be used as a middleware or for individual routes:
+//
+//	m.Get("/reports", makross.Timeout(5*time.Second), reportHandler)
+//
+// The handlers are run in a separate goroutine, and Timeout still waits for them to return
+// so that the Context is not reused while they are running.
+func Timeout(timeout time.Duration, status ...int) Handler {
+	code := StatusServiceUnavailable
+	if len(status) > 0 {
+		code = status[0]
+	}
+
+	return func(c *Context) error {
+		ctx, cancel := ktx.WithTimeout(c.Kontext(), timeout)
+		defer cancel()
+		c.SetKontext(ctx)
+
+		// the handlers may replace the request, e.g. with SetKontext, while the error response is written
+		req, res := c.Request, c.Response.Writer
+		tw := &timeoutWriter{w: res, h: cloneHeader(res.Header()), ctx: ctx}
+		c.Response.Writer = tw
+		defer func() {
+			c.Response.Writer = res
+		}()
+
+		type result struct {
+			err   error
+			panic interface{}
+		}
+		done := make(chan result, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					done <- result{panic: p}
+				}
+			}()
+			done <- result{err: c.Next()}
+		}()
+
+		var r result
+		select {
+		case r = <-done:
+		case <-ctx.Done():
+			timedOut := ctx.Err() == ktx.DeadlineExceeded && tw.timeout()
+			if timedOut {
+				// the handlers are still using c, so the error is written with a context of its own
+				ec := c.makross.AcquireContext()
+				ec.Reset(res, req)
+				ec.route = c.route
+				c.makross.HandleError(ec, NewHTTPError(code))
+				c.makross.ReleaseContext(ec)
+				// the client gets the error response without waiting for the handlers to return
+				if f, ok := res.(http.Flusher); ok {
+					f.Flush()
+				}
+			}
+			r = <-done
+			if timedOut {
+				c.Response.Status = code
+				c.Response.Committed = true
+				r.err = nil
+			}
+		}
+		if r.panic != nil {
+			// let the handlers registered earlier recover from the panic
+			panic(r.panic)
+		}
+		return r.err
+	}
+}
+
+// timeoutWriter passes the response written by the handlers through to w
+// until the response is taken over by Timeout.
+type timeoutWriter struct {
+	w           http.ResponseWriter
+	h           http.Header // the header modified by the handlers, copied to w when the response starts
+	ctx         ktx.Context // the context whose deadline limits the time to start the response
+	mu          sync.Mutex
+	timedOut    bool
+	wroteHeader bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.expired() && !tw.wroteHeader {
+		tw.writeHeader(code)
+	}
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.wroteHeader {
+		if tw.expired() {
+			return 0, http.ErrHandlerTimeout
+		}
+		tw.writeHeader(StatusOK)
+	}
+	return tw.w.Write(b)
+}
+
+// Flush implements the http.Flusher interface if the wrapped writer does.
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if f, ok := tw.w.(http.Flusher); ok && tw.wroteHeader {
+		f.Flush()
+	}
+}
+
+// expired reports whether it is too late for the handlers to start the response.
+func (tw *timeoutWriter) expired() bool {
+	return tw.timedOut || tw.ctx.Err() == ktx.DeadlineExceeded
+}
+
+func (tw *timeoutWriter) writeHeader(code int) {
+	header := tw.w.Header()
+	for k := range header {
+		if _, ok := tw.h[k]; !ok {
+			delete(header, k)
+		}
+	}
+	for k, v := range tw.h {
+		header[k] = v
+	}
+	tw.w.WriteHeader(code)
+	tw.wroteHeader = true
+}
+
+// timeout takes over the response and reports whether it succeeded,
+// which is not the case if the handlers have started writing the response.
+func (tw *timeoutWriter) timeout() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.wroteHeader {
+		return false
+	}
+	tw.timedOut = true
+	return true
+}
+
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
@@ -0,0 +1,114 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	ktx "context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	m := New()
+	writeErr := make(chan error, 1)
+	m.Get("/slow", Timeout(10*time.Millisecond), func(c *Context) error {
+		<-c.Kontext().Done()
+		c.Response.Header().Set("X-Slow", "true")
+		writeErr <- c.String("too late")
+		return nil
+	})
+	m.Get("/gateway", Timeout(10*time.Millisecond, StatusGatewayTimeout), func(c *Context) error {
+		<-c.Kontext().Done()
+		return c.Kontext().Err()
+	})
+	m.Get("/fast", Timeout(time.Second), func(c *Context) error {
+		_, ok := c.Kontext().Deadline()
+		assert.True(t, ok)
+		_, ok = c.Request.Context().Deadline()
+		assert.True(t, ok)
+		c.Response.Header().Set("X-Fast", "true")
+		return c.String("fast")
+	})
+	m.Get("/started", Timeout(10*time.Millisecond), func(c *Context) error {
+		c.Response.WriteHeader(StatusAccepted)
+		<-c.Kontext().Done()
+		_, err := c.Response.Write([]byte("done"))
+		return err
+	})
+	m.Get("/error", Timeout(time.Second), func(c *Context) error {
+		return NewHTTPError(StatusForbidden)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/slow", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusServiceUnavailable, res.Code)
+	assert.Equal(t, "Service Unavailable", res.Body.String())
+	assert.Equal(t, "", res.Header().Get("X-Slow"))
+	assert.True(t, res.Flushed)
+	assert.Equal(t, http.ErrHandlerTimeout, <-writeErr)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/gateway", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusGatewayTimeout, res.Code)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/fast", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusOK, res.Code)
+	assert.Equal(t, "fast", res.Body.String())
+	assert.Equal(t, "true", res.Header().Get("X-Fast"))
+	assert.Equal(t, "Makross", res.Header().Get("Server"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/started", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusAccepted, res.Code)
+	assert.Equal(t, "done", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/error", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusForbidden, res.Code)
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	m := New()
+	m.Get("/panic", func(c *Context) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = c.String("recovered", StatusInternalServerError)
+			}
+		}()
+		return c.Next()
+	}, Timeout(time.Second), func(c *Context) error {
+		panic("failed")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, StatusInternalServerError, res.Code)
+	assert.Equal(t, "recovered", res.Body.String())
+}
+
+func TestContextKontext(t *testing.T) {
+	ctx, cancel := ktx.WithCancel(ktx.Background())
+	req, _ := http.NewRequest("GET", "/", nil)
+	c := New().AcquireContext()
+	c.Reset(httptest.NewRecorder(), req.WithContext(ctx))
+	cancel()
+	select {
+	case <-c.Kontext().Done():
+	default:
+		t.Error("the context is not canceled with the request")
+	}
+
+	c.SetKontext(ktx.WithValue(c.Kontext(), "key", "value"))
+	assert.Equal(t, "value", c.Request.Context().Value("key"))
+}
@@ -0,0 +1,99 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"errors"
+	"reflect"
+)
+
+var (
+	contextType = reflect.TypeOf((*Context)(nil))
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Typed adapts a function with a typed request and response to a Handler.
+// The function must have one of the following forms, where Req is a struct type and Resp is any type:
+//
+//	func(*Context, *Req) (Resp, error)
+//	func(*Context, *Req) error
+//	func(*Context) (Resp, error)
+//
+// A new Req is populated with Context.Bind before the function is called. The returned Resp is written
+// with the given status (StatusOK by default) using the data writer set by content.TypeNegotiator,
+// or by Context.Negotiate with the default offers if no data writer has been set. If Resp is nil or
+// the function returns only an error, the response has no content and the status StatusNoContent,
+// unless another status is given. Nothing is written if the function has written the response itself.
+//
+// Errors returned by Bind or the function are returned by the handler, so that a *HTTPError decides
+// the status of the error response. Typed panics if fn is not a function of the above forms.
+func Typed(fn interface{}, status ...int) Handler {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if err := checkTypedFunc(t); err != nil {
+		panic(err)
+	}
+	code, empty := StatusOK, StatusNoContent
+	if len(status) > 0 {
+		code, empty = status[0], status[0]
+	}
+
+	return func(c *Context) error {
+		in := []reflect.Value{reflect.ValueOf(c)}
+		if t.NumIn() == 2 {
+			req := reflect.New(t.In(1).Elem())
+			if err := c.Bind(req.Interface()); err != nil {
+				return err
+			}
+			in = append(in, req)
+		}
+
+		out := v.Call(in)
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			return err
+		}
+		if c.Response.Committed {
+			// the function has written the response by itself
+			return nil
+		}
+		if len(out) == 1 || isNilValue(out[0]) {
+			return c.NoContent(empty)
+		}
+		return c.writeTyped(out[0], code)
+	}
+}
+
+// checkTypedFunc returns an error if t is not the type of a function accepted by Typed.
+func checkTypedFunc(t reflect.Type) error {
+	if t.Kind() != reflect.Func || t.IsVariadic() {
+		return errors.New("makross: Typed requires a function")
+	}
+	if t.NumIn() < 1 || t.NumIn() > 2 || t.In(0) != contextType {
+		return errors.New("makross: the function given to Typed must accept *Context and an optional request pointer")
+	}
+	if t.NumIn() == 2 && (t.In(1).Kind() != reflect.Ptr || t.In(1).Elem().Kind() != reflect.Struct) {
+		return errors.New("makross: the request accepted by the function given to Typed must be a pointer to a struct")
+	}
+	if t.NumOut() < 1 || t.NumOut() > 2 || t.Out(t.NumOut()-1) != errorType || t.NumOut() == 1 && t.NumIn() == 1 {
+		return errors.New("makross: the function given to Typed must return an optional response and an error")
+	}
+	return nil
+}
+
+// isNilValue returns whether the response returned by a function adapted by Typed is nil.
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// writeTyped writes the response returned by a function adapted by Typed.
+func (c *Context) writeTyped(resp reflect.Value, status int) error {
+	if c.writer == DefaultDataWriter {
+		return c.NegotiateStatus(status, resp.Interface())
+	}
+	c.Response.WriteHeader(status)
+	return c.Write(resp.Interface())
+}
@@ -0,0 +1,142 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedRequest struct {
+	Name string `json:"name" query:"name"`
+}
+
+type typedResponse struct {
+	Greeting string `json:"greeting" xml:"greeting"`
+}
+
+type testDataWriter struct{}
+
+func (w *testDataWriter) SetHeader(res http.ResponseWriter) {
+	res.Header().Set(HeaderContentType, MIMETextPlain)
+}
+
+func (w *testDataWriter) Write(res http.ResponseWriter, data interface{}) error {
+	_, err := fmt.Fprint(res, data)
+	return err
+}
+
+func serveTyped(h Handler, method, body string) *httptest.ResponseRecorder {
+	m := New()
+	m.To(method, "/hello", h)
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, "/hello?name=query", strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	}
+	m.ServeHTTP(res, req)
+	return res
+}
+
+func TestTyped(t *testing.T) {
+	hello := func(c *Context, req *typedRequest) (*typedResponse, error) {
+		if req.Name == "" {
+			return nil, NewHTTPError(http.StatusUnprocessableEntity, "name is required")
+		}
+		if req.Name == "nobody" {
+			return nil, nil
+		}
+		return &typedResponse{"hello " + req.Name}, nil
+	}
+
+	res := serveTyped(Typed(hello, http.StatusCreated), "POST", `{"name":"john"}`)
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, `{"greeting":"hello john"}`, res.Body.String())
+
+	res = serveTyped(Typed(hello), "GET", "")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `{"greeting":"hello query"}`, res.Body.String())
+
+	res = serveTyped(Typed(hello), "POST", `{}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.Equal(t, "name is required", res.Body.String())
+
+	res = serveTyped(Typed(hello), "POST", `{"name":`)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	res = serveTyped(Typed(hello), "POST", `{"name":"nobody"}`)
+	assert.Equal(t, http.StatusNoContent, res.Code)
+
+	// a nil response is written with the given status
+	res = serveTyped(Typed(hello, http.StatusAccepted), "POST", `{"name":"nobody"}`)
+	assert.Equal(t, http.StatusAccepted, res.Code)
+	assert.Equal(t, "", res.Body.String())
+
+	res = serveTyped(Typed(func(c *Context, req *typedRequest) error {
+		return errors.New("failed")
+	}), "POST", `{"name":"john"}`)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, "failed", res.Body.String())
+
+	res = serveTyped(Typed(func(c *Context, req *typedRequest) error {
+		return nil
+	}), "POST", `{"name":"john"}`)
+	assert.Equal(t, http.StatusNoContent, res.Code)
+
+	res = serveTyped(Typed(func(c *Context) (string, error) {
+		return "ok", nil
+	}), "GET", "")
+	assert.Equal(t, `"ok"`, res.Body.String())
+
+	res = serveTyped(Typed(func(c *Context) (interface{}, error) {
+		return nil, c.Redirect("/")
+	}), "GET", "")
+	assert.Equal(t, http.StatusFound, res.Code)
+}
+
+func TestTypedDataWriter(t *testing.T) {
+	m := New()
+	m.Get("/hello", func(c *Context) error {
+		c.SetDataWriter(&testDataWriter{})
+		return nil
+	}, Typed(func(c *Context) (*typedResponse, error) {
+		return &typedResponse{"hello"}, nil
+	}))
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/hello", nil)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, "&{hello}", res.Body.String())
+}
+
+func TestTypedInvalid(t *testing.T) {
+	invalid := []interface{}{
+		"abc",
+		func() error { return nil },
+		func(c *Context) error { return nil },
+		func(c *Context, req typedRequest) error { return nil },
+		func(c *Context, req *typedRequest) string { return "" },
+		func(c *Context, req *typedRequest) (string, string) { return "", "" },
+	}
+	for _, fn := range invalid {
+		assert.Panics(t, func() { Typed(fn) })
+	}
+}
+
+func TestTypedNegotiate(t *testing.T) {
+	m := New()
+	m.Get("/hello", Typed(func(c *Context) (*typedResponse, error) {
+		return &typedResponse{"hello"}, nil
+	}))
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/hello", nil)
+	req.Header.Set(HeaderAccept, MIMEApplicationXML)
+	m.ServeHTTP(res, req)
+	assert.Equal(t, MIMEApplicationXMLCharsetUTF8, res.Header().Get(HeaderContentType))
+	assert.Contains(t, res.Body.String(), "<greeting>hello</greeting>")
+}
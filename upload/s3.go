@@ -0,0 +1,307 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// minPartSize is the minimum size of the parts of an S3 multipart upload except the last one.
+const minPartSize = 5 << 20
+
+// S3Sink stores files in a bucket of an S3-compatible object storage (Amazon S3, MinIO, Ceph, etc.)
+// using path-style requests signed with AWS Signature Version 4.
+//
+// A file is buffered up to PartSize bytes: smaller files are stored with a single PUT request,
+// and larger files are streamed as a multipart upload, one part at a time.
+type S3Sink struct {
+	// Endpoint is the base URL of the storage, e.g. "https://s3.us-east-1.amazonaws.com".
+	Endpoint string
+	// Region is the region of the bucket. Default value "us-east-1".
+	Region string
+	// Bucket is the name of the bucket.
+	Bucket string
+	// AccessKey and SecretKey are the credentials used to sign the requests.
+	AccessKey string
+	SecretKey string
+	// Prefix is prepended to the keys of the stored objects, e.g. "uploads/".
+	Prefix string
+	// PartSize is the size of the parts of multipart uploads. Default and minimum value 5 MB.
+	PartSize int64
+	// Name returns the key of the stored object without the prefix. Default value RandomName.
+	Name func(f *File) string
+	// Client is the HTTP client used to send the requests. Default value http.DefaultClient.
+	Client *http.Client
+}
+
+// Save implements Sink.Save.
+func (s *S3Sink) Save(ctx context.Context, f *File, r io.Reader) error {
+	name := RandomName
+	if s.Name != nil {
+		name = s.Name
+	}
+	key := s.Prefix + name(f)
+	size := s.PartSize
+	if size < minPartSize {
+		size = minPartSize
+	}
+
+	buf := make([]byte, size)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		header := http.Header{}
+		header.Set("Content-Type", f.ContentType)
+		if _, err = s.do(ctx, "PUT", key, nil, header, buf[:n]); err != nil {
+			return err
+		}
+		f.Location = s.objectURL(key)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	id, err := s.createMultipartUpload(ctx, key, f.ContentType)
+	if err != nil {
+		return err
+	}
+	if err = s.uploadParts(ctx, key, id, r, buf); err != nil {
+		s.do(context.Background(), "DELETE", key, url.Values{"uploadId": {id}}, nil, nil)
+		return err
+	}
+	f.Location = s.objectURL(key)
+	return nil
+}
+
+// Remove implements Sink.Remove.
+func (s *S3Sink) Remove(ctx context.Context, f *File) error {
+	key, err := url.PathUnescape(strings.TrimPrefix(f.Location, s.objectURL("")))
+	if err != nil {
+		return err
+	}
+	_, err = s.do(ctx, "DELETE", key, nil, nil, nil)
+	return err
+}
+
+type (
+	completedPart struct {
+		PartNumber int
+		ETag       string
+	}
+
+	completeMultipartUpload struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}
+)
+
+func (s *S3Sink) createMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	res, err := s.do(ctx, "POST", key, url.Values{"uploads": {""}}, header, nil)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err = xml.Unmarshal(res, &result); err != nil {
+		return "", err
+	}
+	return result.UploadID, nil
+}
+
+// uploadParts uploads the part already read in buf and the rest of r as a multipart upload and completes it.
+func (s *S3Sink) uploadParts(ctx context.Context, key, id string, r io.Reader, buf []byte) error {
+	complete := completeMultipartUpload{}
+	data := buf
+	for number := 1; len(data) > 0; number++ {
+		query := url.Values{"partNumber": {fmt.Sprint(number)}, "uploadId": {id}}
+		req, err := s.newRequest(ctx, "PUT", key, query, nil, data)
+		if err != nil {
+			return err
+		}
+		res, err := s.send(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		complete.Parts = append(complete.Parts, completedPart{number, res.Header.Get("ETag")})
+
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		data = buf[:n]
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	res, err := s.do(ctx, "POST", key, url.Values{"uploadId": {id}}, nil, body)
+	if err != nil {
+		return err
+	}
+	// completing an upload may fail after the response status has been sent
+	if bytes.Contains(res, []byte("<Error>")) {
+		return fmt.Errorf("upload: completing multipart upload of %s failed: %s", key, res)
+	}
+	return nil
+}
+
+func (s *S3Sink) objectURL(key string) string {
+	return strings.TrimRight(s.Endpoint, "/") + "/" + s.Bucket + "/" + escapePath(key)
+}
+
+// do sends a signed request and returns the response body.
+func (s *S3Sink) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) ([]byte, error) {
+	req, err := s.newRequest(ctx, method, key, query, header, body)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	return ioutil.ReadAll(res.Body)
+}
+
+func (s *S3Sink) send(req *http.Request) (*http.Response, error) {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode/100 != 2 {
+		defer res.Body.Close()
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("upload: %s %s: %s: %s", req.Method, req.URL.Path, res.Status, msg)
+	}
+	return res, nil
+}
+
+func (s *S3Sink) newRequest(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Request, error) {
+	u := s.objectURL(key)
+	if len(query) > 0 {
+		u += "?" + canonicalQuery(query)
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	region := s.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	signV4(req, body, s.AccessKey, s.SecretKey, region, time.Now())
+	return req, nil
+}
+
+// signV4 signs the request for S3 with AWS Signature Version 4. All headers of the request are signed.
+func signV4(req *http.Request, body []byte, accessKey, secretKey, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery encodes the query sorted by key with the URI encoding required by Signature Version 4.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escapePath encodes each segment of the path.
+func escapePath(path string) string {
+	return uriEncode(path, false)
+}
+
+// uriEncode encodes every byte except the unreserved characters, and "/" unless encodeSlash is true.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' && !encodeSlash {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type (
+	// Sink stores uploaded files.
+	Sink interface {
+		// Save stores the data read from r as the given file and sets the file's Location.
+		// The reader fails with ErrFileTooLarge once the file exceeds the size limit, in which
+		// case Save must discard the partially stored data.
+		Save(ctx context.Context, f *File, r io.Reader) error
+		// Remove removes a file previously stored by Save.
+		Remove(ctx context.Context, f *File) error
+	}
+
+	// LocalSink stores files in a local directory.
+	LocalSink struct {
+		// Dir is the directory where files are stored. It is created if it does not exist.
+		Dir string
+		// Perm is the permission of the stored files. Default value 0644.
+		Perm os.FileMode
+		// Name returns the name of the stored file. Default value RandomName.
+		Name func(f *File) string
+	}
+
+	// MemorySink keeps files in memory, which is mainly useful for small files and tests.
+	MemorySink struct {
+		mu    sync.RWMutex
+		files map[string][]byte
+	}
+)
+
+// ErrFileNotFound is returned by MemorySink.Remove when the file is not stored.
+var ErrFileNotFound = errors.New("upload: file not found")
+
+// RandomName returns a random file name that keeps the extension of the client's file name.
+// The client's file name is never used as is, so that it cannot be used to escape the storage.
+func RandomName(f *File) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	ext := strings.ToLower(filepath.Ext(filepath.Base(f.Filename)))
+	for _, r := range strings.TrimPrefix(ext, ".") {
+		if !('a' <= r && r <= 'z' || '0' <= r && r <= '9') || len(ext) > 16 {
+			ext = ""
+			break
+		}
+	}
+	if ext == "." {
+		ext = ""
+	}
+	return hex.EncodeToString(b) + ext
+}
+
+// NewLocalSink returns a sink storing files in the given directory.
+func NewLocalSink(dir string) *LocalSink {
+	return &LocalSink{Dir: dir}
+}
+
+// Save implements Sink.Save.
+func (s *LocalSink) Save(ctx context.Context, f *File, r io.Reader) error {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+	name := RandomName
+	if s.Name != nil {
+		name = s.Name
+	}
+	perm := s.Perm
+	if perm == 0 {
+		perm = 0644
+	}
+
+	path := filepath.Join(s.Dir, filepath.Base(name(f)))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, r)
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	f.Location = path
+	return nil
+}
+
+// Remove implements Sink.Remove.
+func (s *LocalSink) Remove(ctx context.Context, f *File) error {
+	return os.Remove(f.Location)
+}
+
+// NewMemorySink returns a sink keeping files in memory.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{files: map[string][]byte{}}
+}
+
+// Save implements Sink.Save.
+func (s *MemorySink) Save(ctx context.Context, f *File, r io.Reader) error {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r); err != nil {
+		return err
+	}
+	f.Location = RandomName(f)
+	s.mu.Lock()
+	s.files[f.Location] = buf.Bytes()
+	s.mu.Unlock()
+	return nil
+}
+
+// Remove implements Sink.Remove.
+func (s *MemorySink) Remove(ctx context.Context, f *File) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[f.Location]; !ok {
+		return ErrFileNotFound
+	}
+	delete(s.files, f.Location)
+	return nil
+}
+
+// Bytes returns the data of the file stored at the given location.
+func (s *MemorySink) Bytes(location string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.files[location]
+	return b, ok
+}
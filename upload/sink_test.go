@@ -0,0 +1,74 @@
+package upload
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomName(t *testing.T) {
+	tests := []struct {
+		filename, ext string
+	}{
+		{"photo.PNG", ".png"},
+		{"../../etc/passwd", ""},
+		{"archive.tar.gz", ".gz"},
+		{"evil.p/hp", ""},
+		{"evil.ph p", ""},
+		{"dot.", ""},
+	}
+	for _, test := range tests {
+		name := RandomName(&File{Filename: test.filename})
+		assert.Len(t, name, 32+len(test.ext), test.filename)
+		assert.True(t, strings.HasSuffix(name, test.ext), test.filename)
+	}
+	assert.NotEqual(t, RandomName(&File{}), RandomName(&File{}))
+}
+
+func TestLocalSink(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "upload")
+	defer os.RemoveAll(dir)
+	sink := NewLocalSink(filepath.Join(dir, "files"))
+	ctx := context.Background()
+
+	f := &File{Filename: "a.txt"}
+	assert.Nil(t, sink.Save(ctx, f, strings.NewReader("hello")))
+	assert.Equal(t, filepath.Join(dir, "files"), filepath.Dir(f.Location))
+	b, err := ioutil.ReadFile(f.Location)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(b))
+	assert.Nil(t, sink.Remove(ctx, f))
+	_, err = os.Stat(f.Location)
+	assert.True(t, os.IsNotExist(err))
+
+	// a failed upload leaves no file behind
+	sink.Name = func(f *File) string { return "../fixed.txt" }
+	r := &limitedReader{r: strings.NewReader("too large"), limit: 3, file: &File{}}
+	assert.Equal(t, ErrFileTooLarge, sink.Save(ctx, &File{}, r))
+	files, _ := ioutil.ReadDir(filepath.Join(dir, "files"))
+	assert.Empty(t, files)
+
+	f = &File{}
+	assert.Nil(t, sink.Save(ctx, f, strings.NewReader("hello")))
+	assert.Equal(t, filepath.Join(dir, "files", "fixed.txt"), f.Location)
+	assert.NotNil(t, sink.Save(ctx, &File{}, strings.NewReader("hello")))
+}
+
+func TestMemorySink(t *testing.T) {
+	sink := NewMemorySink()
+	ctx := context.Background()
+	f := &File{Filename: "a.txt"}
+	assert.Nil(t, sink.Save(ctx, f, strings.NewReader("hello")))
+	b, ok := sink.Bytes(f.Location)
+	assert.True(t, ok)
+	assert.Equal(t, "hello", string(b))
+	assert.Nil(t, sink.Remove(ctx, f))
+	assert.Equal(t, ErrFileNotFound, sink.Remove(ctx, f))
+	_, ok = sink.Bytes(f.Location)
+	assert.False(t, ok)
+}
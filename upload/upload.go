@@ -0,0 +1,336 @@
+// Package upload implements streaming multipart uploads for the makross.
+//
+// The parts of a multipart/form-data request are processed as they arrive: form fields are
+// read into memory up to a size limit, and files are sniffed for their content type and
+// streamed into a Sink without being buffered in memory or in temporary files first.
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"github.com/insionng/makross"
+	"github.com/insionng/makross/skipper"
+)
+
+// sniffLen is the number of bytes used to detect the content type of a file.
+const sniffLen = 512
+
+type (
+	// UploadConfig defines the config for streaming multipart uploads.
+	UploadConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper skipper.Skipper
+
+		// Sink stores the uploaded files.
+		// Required.
+		Sink Sink
+
+		// MaxFileSize is the maximum size in bytes of each uploaded file.
+		// Optional. Default value 32 MB.
+		MaxFileSize int64
+
+		// MaxFieldSize is the maximum size in bytes of each form field value.
+		// Optional. Default value 1 MB.
+		MaxFieldSize int64
+
+		// MaxFiles is the maximum number of files in a request.
+		// Optional. Default value 10.
+		MaxFiles int
+
+		// MaxFields is the maximum number of form fields in a request.
+		// Optional. Default value 100.
+		MaxFields int
+
+		// AllowedTypes lists the content types allowed for the uploaded files, e.g. "image/png" or "image/*".
+		// The content type is sniffed from the file data, the type declared by the client is ignored.
+		// Optional. Default value []string{}, which allows all types.
+		AllowedTypes []string
+
+		// Progress is called after each chunk of a file has been stored with the number of bytes
+		// written so far.
+		// Optional.
+		Progress func(f *File, written int64)
+
+		// ContextKey is the key used by the Upload middleware to store the parsed Form in the context.
+		// Optional. Default value "upload".
+		ContextKey string
+	}
+
+	// File describes an uploaded file.
+	File struct {
+		// Field is the name of the form field of the file.
+		Field string
+		// Filename is the file name given by the client.
+		Filename string
+		// Header is the MIME header of the part.
+		Header textproto.MIMEHeader
+		// ContentType is the content type sniffed from the file data.
+		ContentType string
+		// Size is the number of bytes stored.
+		Size int64
+		// Location identifies the stored file in the sink, such as a path or a URL.
+		Location string
+	}
+
+	// Form is a parsed multipart form whose files have been stored in a sink.
+	Form struct {
+		Value url.Values
+		File  map[string][]*File
+	}
+)
+
+var (
+	// DefaultUploadConfig is the default Upload middleware config.
+	DefaultUploadConfig = UploadConfig{
+		Skipper:      skipper.DefaultSkipper,
+		MaxFileSize:  32 << 20,
+		MaxFieldSize: 1 << 20,
+		MaxFiles:     10,
+		MaxFields:    100,
+		ContextKey:   "upload",
+	}
+
+	// ErrFileTooLarge is returned when a file exceeds MaxFileSize.
+	ErrFileTooLarge = makross.NewHTTPError(makross.StatusRequestEntityTooLarge, "file too large")
+	// ErrFieldTooLarge is returned when a form field exceeds MaxFieldSize.
+	ErrFieldTooLarge = makross.NewHTTPError(makross.StatusRequestEntityTooLarge, "form field too large")
+	// ErrTooManyFiles is returned when a request has more than MaxFiles files.
+	ErrTooManyFiles = makross.NewHTTPError(makross.StatusRequestEntityTooLarge, "too many files")
+	// ErrTooManyFields is returned when a request has more than MaxFields form fields.
+	ErrTooManyFields = makross.NewHTTPError(makross.StatusRequestEntityTooLarge, "too many form fields")
+	// ErrTypeNotAllowed is returned when the content type of a file is not in AllowedTypes.
+	ErrTypeNotAllowed = makross.NewHTTPError(makross.StatusUnsupportedMediaType, "file type not allowed")
+)
+
+// Upload returns an Upload middleware which stores the files in the sink.
+//
+// Upload middleware parses the multipart/form-data request body with Parse and stores the
+// resulting *Form in the context under the "upload" key before calling the next handler:
+//
+//	form := c.Get("upload").(*upload.Form)
+func Upload(sink Sink) makross.Handler {
+	c := DefaultUploadConfig
+	c.Sink = sink
+	return UploadWithConfig(c)
+}
+
+// UploadWithConfig returns an Upload middleware with config.
+// See: `Upload()`.
+func UploadWithConfig(config UploadConfig) makross.Handler {
+	config = withDefaults(config)
+	if config.Sink == nil {
+		panic("upload: sink is required")
+	}
+	return func(c *makross.Context) error {
+		if config.Skipper(c) {
+			return c.Next()
+		}
+		form, err := Parse(c, config)
+		if err != nil {
+			return err
+		}
+		c.Set(config.ContextKey, form)
+		return c.Next()
+	}
+}
+
+// Parse reads the multipart/form-data body of the request part by part, and stores each file
+// in the sink of the config as soon as it arrives. Zero limits in the config take their values
+// from DefaultUploadConfig. If any part is rejected or fails to be stored, the files already
+// stored are removed from the sink and the error is returned.
+func Parse(c *makross.Context, config UploadConfig) (*Form, error) {
+	config = withDefaults(config)
+	if config.Sink == nil {
+		panic("upload: sink is required")
+	}
+
+	mr, err := c.Request.MultipartReader()
+	if err == http.ErrNotMultipart {
+		return nil, makross.ErrUnsupportedMediaType
+	} else if err != nil {
+		return nil, makross.NewHTTPError(makross.StatusBadRequest, err.Error())
+	}
+
+	ctx := c.Kontext()
+	form := &Form{Value: url.Values{}, File: map[string][]*File{}}
+	if err = parse(ctx, mr, config, form); err != nil {
+		form.RemoveAll(ctx, config.Sink)
+		return nil, err
+	}
+	return form, nil
+}
+
+func parse(ctx context.Context, mr *multipart.Reader, config UploadConfig, form *Form) error {
+	files, fields := 0, 0
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return makross.NewHTTPError(makross.StatusBadRequest, err.Error())
+		}
+
+		name := part.FormName()
+		if name == "" {
+			part.Close()
+			continue
+		}
+		if part.FileName() == "" {
+			if fields++; fields > config.MaxFields {
+				return ErrTooManyFields
+			}
+			err = readField(part, config, form)
+		} else {
+			if files++; files > config.MaxFiles {
+				return ErrTooManyFiles
+			}
+			err = storeFile(ctx, part, config, form)
+		}
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func readField(part *multipart.Part, config UploadConfig, form *Form) error {
+	var buf bytes.Buffer
+	n, err := buf.ReadFrom(io.LimitReader(part, config.MaxFieldSize+1))
+	if err != nil {
+		return makross.NewHTTPError(makross.StatusBadRequest, err.Error())
+	}
+	if n > config.MaxFieldSize {
+		return ErrFieldTooLarge
+	}
+	form.Value.Add(part.FormName(), buf.String())
+	return nil
+}
+
+func storeFile(ctx context.Context, part *multipart.Part, config UploadConfig, form *Form) error {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(part, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return makross.NewHTTPError(makross.StatusBadRequest, err.Error())
+	}
+	head = head[:n]
+
+	f := &File{
+		Field:       part.FormName(),
+		Filename:    part.FileName(),
+		Header:      part.Header,
+		ContentType: http.DetectContentType(head),
+	}
+	if !isAllowed(f.ContentType, config.AllowedTypes) {
+		return ErrTypeNotAllowed
+	}
+
+	r := &limitedReader{
+		r:        io.MultiReader(bytes.NewReader(head), part),
+		limit:    config.MaxFileSize,
+		file:     f,
+		progress: config.Progress,
+	}
+	err = config.Sink.Save(ctx, f, r)
+	if r.exceeded {
+		return ErrFileTooLarge
+	}
+	if err != nil {
+		return err
+	}
+	form.File[f.Field] = append(form.File[f.Field], f)
+	return nil
+}
+
+// Get returns the first value of the named form field.
+func (f *Form) Get(name string) string {
+	return f.Value.Get(name)
+}
+
+// FirstFile returns the first file uploaded with the named form field, or nil if there is none.
+func (f *Form) FirstFile(name string) *File {
+	if files := f.File[name]; len(files) > 0 {
+		return files[0]
+	}
+	return nil
+}
+
+// RemoveAll removes all files of the form from the sink.
+func (f *Form) RemoveAll(ctx context.Context, sink Sink) error {
+	var err error
+	for _, files := range f.File {
+		for _, file := range files {
+			if e := sink.Remove(ctx, file); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+// limitedReader counts the bytes read from a file part, reports the progress and fails
+// once the limit is exceeded.
+type limitedReader struct {
+	r        io.Reader
+	limit    int64
+	file     *File
+	progress func(f *File, written int64)
+	exceeded bool
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.exceeded {
+		return 0, ErrFileTooLarge
+	}
+	n, err := r.r.Read(p)
+	r.file.Size += int64(n)
+	if r.file.Size > r.limit {
+		r.exceeded = true
+		return 0, ErrFileTooLarge
+	}
+	if n > 0 && r.progress != nil {
+		r.progress(r.file, r.file.Size)
+	}
+	return n, err
+}
+
+func isAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	for _, t := range allowed {
+		if t == mediaType || t == "*/*" || strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func withDefaults(config UploadConfig) UploadConfig {
+	if config.Skipper == nil {
+		config.Skipper = DefaultUploadConfig.Skipper
+	}
+	if config.MaxFileSize == 0 {
+		config.MaxFileSize = DefaultUploadConfig.MaxFileSize
+	}
+	if config.MaxFieldSize == 0 {
+		config.MaxFieldSize = DefaultUploadConfig.MaxFieldSize
+	}
+	if config.MaxFiles == 0 {
+		config.MaxFiles = DefaultUploadConfig.MaxFiles
+	}
+	if config.MaxFields == 0 {
+		config.MaxFields = DefaultUploadConfig.MaxFields
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultUploadConfig.ContextKey
+	}
+	return config
+}
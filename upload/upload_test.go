@@ -0,0 +1,144 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/insionng/makross"
+	"github.com/stretchr/testify/assert"
+)
+
+var pngData = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+
+type part struct {
+	field, filename string
+	data            []byte
+}
+
+func newUploadRequest(parts ...part) *http.Request {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for _, p := range parts {
+		if p.filename == "" {
+			w.WriteField(p.field, string(p.data))
+			continue
+		}
+		fw, _ := w.CreateFormFile(p.field, p.filename)
+		fw.Write(p.data)
+	}
+	w.Close()
+	req := httptest.NewRequest(makross.POST, "/", body)
+	req.Header.Set(makross.HeaderContentType, w.FormDataContentType())
+	return req
+}
+
+func parseRequest(req *http.Request, config UploadConfig) (*Form, error) {
+	m := makross.New()
+	c := m.NewContext(req, httptest.NewRecorder())
+	return Parse(c, config)
+}
+
+func TestParse(t *testing.T) {
+	sink := NewMemorySink()
+	var progress []int64
+	config := UploadConfig{
+		Sink:         sink,
+		AllowedTypes: []string{"image/*", "text/plain"},
+		Progress: func(f *File, written int64) {
+			progress = append(progress, written)
+		},
+	}
+	req := newUploadRequest(
+		part{"title", "", []byte("holiday")},
+		part{"photo", "photo.png", pngData},
+		part{"photo", "notes.txt", []byte("hello")},
+	)
+	form, err := parseRequest(req, config)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "holiday", form.Get("title"))
+		assert.Len(t, form.File["photo"], 2)
+
+		f := form.FirstFile("photo")
+		assert.Equal(t, "photo", f.Field)
+		assert.Equal(t, "photo.png", f.Filename)
+		assert.Equal(t, "image/png", f.ContentType)
+		assert.Equal(t, int64(len(pngData)), f.Size)
+		b, ok := sink.Bytes(f.Location)
+		assert.True(t, ok)
+		assert.Equal(t, pngData, b)
+
+		f = form.File["photo"][1]
+		assert.Equal(t, "text/plain; charset=utf-8", f.ContentType)
+		assert.Equal(t, []int64{int64(len(pngData)), 5}, progress)
+		assert.Nil(t, form.FirstFile("missing"))
+
+		assert.Nil(t, form.RemoveAll(context.Background(), sink))
+		assert.Empty(t, sink.files)
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	sink := NewMemorySink()
+	tests := []struct {
+		config UploadConfig
+		parts  []part
+		err    error
+	}{
+		{UploadConfig{MaxFileSize: 10}, []part{{"a", "a.txt", []byte("small")}, {"b", "b.txt", []byte("more than ten bytes")}}, ErrFileTooLarge},
+		{UploadConfig{MaxFieldSize: 3}, []part{{"a", "", []byte("abcd")}}, ErrFieldTooLarge},
+		{UploadConfig{MaxFiles: 1}, []part{{"a", "a.txt", []byte("a")}, {"b", "b.txt", []byte("b")}}, ErrTooManyFiles},
+		{UploadConfig{MaxFields: 1}, []part{{"a", "", []byte("a")}, {"b", "", []byte("b")}}, ErrTooManyFields},
+		{UploadConfig{AllowedTypes: []string{"image/png"}}, []part{{"a", "a.png", []byte("not a png")}}, ErrTypeNotAllowed},
+	}
+	for _, test := range tests {
+		test.config.Sink = sink
+		form, err := parseRequest(newUploadRequest(test.parts...), test.config)
+		assert.Nil(t, form)
+		assert.Equal(t, test.err, err)
+		// the files stored before the error are removed
+		assert.Empty(t, sink.files)
+	}
+
+	req := httptest.NewRequest(makross.POST, "/", strings.NewReader("{}"))
+	req.Header.Set(makross.HeaderContentType, makross.MIMEApplicationJSON)
+	_, err := parseRequest(req, UploadConfig{Sink: sink})
+	assert.Equal(t, makross.ErrUnsupportedMediaType, err)
+
+	assert.Panics(t, func() {
+		parseRequest(newUploadRequest(), UploadConfig{})
+	})
+}
+
+func TestUpload(t *testing.T) {
+	sink := NewMemorySink()
+	m := makross.New()
+	m.Post("/photos", Upload(sink), func(c *makross.Context) error {
+		form := c.Get("upload").(*Form)
+		return c.String(form.FirstFile("photo").ContentType)
+	})
+
+	req := newUploadRequest(part{"photo", "photo.png", pngData})
+	req.URL.Path = "/photos"
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "image/png", res.Body.String())
+	assert.Len(t, sink.files, 1)
+
+	assert.Panics(t, func() {
+		UploadWithConfig(UploadConfig{})
+	})
+}
+
+func TestIsAllowed(t *testing.T) {
+	assert.True(t, isAllowed("image/png", nil))
+	assert.True(t, isAllowed("image/png", []string{"image/png"}))
+	assert.True(t, isAllowed("text/plain; charset=utf-8", []string{"text/*"}))
+	assert.True(t, isAllowed("application/pdf", []string{"*/*"}))
+	assert.False(t, isAllowed("image/png", []string{"image/gif", "text/*"}))
+}
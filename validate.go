@@ -0,0 +1,405 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const validateTag = "validate"
+
+type (
+	// Validator validates the data populated by Context.Bind.
+	Validator interface {
+		// Validate returns ValidationErrors if the data is invalid. Other errors are returned as is by Context.Bind.
+		Validate(i interface{}) error
+	}
+
+	// DefaultValidator validates structs according to the rules listed in their "validate" field tags.
+	//
+	// The rules are separated by commas:
+	//
+	//	required     the value must not be the zero value (nil, 0, "", or empty)
+	//	omitempty    the other rules are skipped if the value is the zero value
+	//	min=n        numbers must be at least n, strings (in characters), slices and maps must have at least n items
+	//	max=n        numbers must be at most n, strings, slices and maps must have at most n items
+	//	len=n        numbers must be n, strings, slices and maps must have n items
+	//	email        the string must be an email address
+	//	oneof=a b c  the value must be one of the space separated values
+	//	regexp=re    the string must match the regular expression, which takes the rest of the tag
+	//	dive         the rules after it apply to each item of the slice, array or map
+	//
+	// Nested structs, pointers to structs and the structs in slices and maps are validated as well.
+	// More rules can be added with RegisterValidation. Unknown rules give an error; the tags written for
+	// another validator need that validator to be set with Makross.SetValidator.
+	DefaultValidator struct{}
+
+	// ValidationFunc reports whether the value satisfies a validation rule with the given parameter.
+	ValidationFunc func(v reflect.Value, param string) bool
+
+	// FieldError describes a field failing a validation rule.
+	FieldError struct {
+		// Field is the path of the field, such as "address.city" or "tags[1]", using the names of the json tags.
+		Field string `json:"field" xml:"field"`
+		// Rule is the name of the failing rule.
+		Rule string `json:"rule" xml:"rule"`
+		// Param is the parameter of the rule.
+		Param string `json:"param,omitempty" xml:"param,omitempty"`
+		// Message describes the error, translated by the Localer of the context.
+		Message string `json:"message" xml:"message"`
+
+		key string // the ID of the message
+	}
+
+	// ValidationErrors lists the fields failing validation.
+	ValidationErrors []*FieldError
+)
+
+var (
+	// ValidationMessages lists the default messages of the validation errors by their ID,
+	// which is the name of the rule, or the name followed by "_length" for the length of
+	// strings, slices and maps. "{field}" and "{param}" are replaced by the field name and
+	// the rule parameter. The messages are translated by the Localer of the context with the
+	// keys "validation.<ID>" if they are defined.
+	ValidationMessages = map[string]string{
+		"required":   "{field} is required",
+		"min":        "{field} must be at least {param}",
+		"max":        "{field} must be at most {param}",
+		"len":        "{field} must be {param}",
+		"min_length": "{field} must have a length of at least {param}",
+		"max_length": "{field} must have a length of at most {param}",
+		"len_length": "{field} must have a length of {param}",
+		"email":      "{field} must be a valid email address",
+		"oneof":      "{field} must be one of [{param}]",
+		"regexp":     "{field} has an invalid format",
+		"invalid":    "{field} is invalid",
+	}
+
+	validations   = map[string]ValidationFunc{}
+	validationsMu sync.RWMutex
+
+	emailPattern = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+	regexps      sync.Map // map[string]*regexp.Regexp
+)
+
+// RegisterValidation registers a validation rule that can be used in the "validate" tags.
+// The message of its errors has the ID of the rule name, or "invalid" if there is none in ValidationMessages.
+func RegisterValidation(name string, fn ValidationFunc) {
+	validationsMu.Lock()
+	defer validationsMu.Unlock()
+	validations[name] = fn
+}
+
+// Error returns the messages of the errors separated by semicolons.
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate implements Validator.Validate. Values that are not structs or pointers to structs are not validated.
+func (v *DefaultValidator) Validate(i interface{}) error {
+	rv := reflect.ValueOf(i)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	errs := ValidationErrors{}
+	if err := validateStruct(rv, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		errs.translate(nil)
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(rv reflect.Value, prefix string, errs *ValidationErrors) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get(validateTag)
+		if tag == "-" {
+			continue
+		}
+		name := prefix
+		if !field.Anonymous {
+			name = joinFieldName(prefix, fieldName(field))
+		}
+		if err := validateValue(rv.Field(i), name, tag, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateValue checks the value against the rules of the tag and validates the nested structs.
+func validateValue(v reflect.Value, name, tag string, errs *ValidationErrors) error {
+	rules := splitRules(tag)
+	for len(rules) > 0 {
+		rule, param := rules[0][0], rules[0][1]
+		rules = rules[1:]
+
+		switch rule {
+		case "":
+			continue
+		case "omitempty":
+			if isZeroValue(v) {
+				return nil
+			}
+			continue
+		case "required":
+			if isZeroValue(v) {
+				errs.add(name, rule, param, "required")
+				return nil
+			}
+			continue
+		case "dive":
+			v = indirectValue(v)
+			if !v.IsValid() {
+				return nil
+			}
+			diveTag := joinRules(rules)
+			switch v.Kind() {
+			case reflect.Slice, reflect.Array:
+				for i := 0; i < v.Len(); i++ {
+					if err := validateValue(v.Index(i), fmt.Sprintf("%v[%v]", name, i), diveTag, errs); err != nil {
+						return err
+					}
+				}
+			case reflect.Map:
+				for _, key := range v.MapKeys() {
+					if err := validateValue(v.MapIndex(key), fmt.Sprintf("%v[%v]", name, key.Interface()), diveTag, errs); err != nil {
+						return err
+					}
+				}
+			default:
+				return fmt.Errorf("makross: cannot dive into %v of field %v", v.Type(), name)
+			}
+			return nil
+		}
+
+		iv := indirectValue(v)
+		if !iv.IsValid() {
+			// nil pointers are only checked by required
+			return nil
+		}
+		ok, key, err := checkRule(iv, rule, param)
+		if err != nil {
+			return fmt.Errorf("makross: %v of field %v", err, name)
+		}
+		if !ok {
+			errs.add(name, rule, param, key)
+			return nil
+		}
+	}
+
+	// validate nested structs
+	v = indirectValue(v)
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return validateStruct(v, name, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if e := indirectValue(v.Index(i)); e.IsValid() && e.Kind() == reflect.Struct {
+				if err := validateStruct(e, fmt.Sprintf("%v[%v]", name, i), errs); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if e := indirectValue(v.MapIndex(key)); e.IsValid() && e.Kind() == reflect.Struct {
+				if err := validateStruct(e, fmt.Sprintf("%v[%v]", name, key.Interface()), errs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkRule reports whether the value satisfies the rule, and the ID of the error message if it does not.
+func checkRule(v reflect.Value, rule, param string) (bool, string, error) {
+	switch rule {
+	case "min", "max", "len":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false, "", fmt.Errorf("invalid parameter %q of rule %v", param, rule)
+		}
+		var x float64
+		key := rule
+		switch v.Kind() {
+		case reflect.String:
+			x, key = float64(utf8.RuneCountInString(v.String())), rule+"_length"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			x, key = float64(v.Len()), rule+"_length"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			x = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			x = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			x = v.Float()
+		default:
+			return false, "", fmt.Errorf("rule %v cannot be applied to %v", rule, v.Type())
+		}
+		switch rule {
+		case "min":
+			return x >= n, key, nil
+		case "max":
+			return x <= n, key, nil
+		}
+		return x == n, key, nil
+	case "email":
+		if v.Kind() != reflect.String {
+			return false, "", fmt.Errorf("rule email cannot be applied to %v", v.Type())
+		}
+		return emailPattern.MatchString(v.String()), rule, nil
+	case "regexp":
+		if v.Kind() != reflect.String {
+			return false, "", fmt.Errorf("rule regexp cannot be applied to %v", v.Type())
+		}
+		re, err := compileRegexp(param)
+		if err != nil {
+			return false, "", err
+		}
+		return re.MatchString(v.String()), rule, nil
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, option := range strings.Fields(param) {
+			if s == option {
+				return true, rule, nil
+			}
+		}
+		return false, rule, nil
+	}
+
+	validationsMu.RLock()
+	fn, ok := validations[rule]
+	validationsMu.RUnlock()
+	if !ok {
+		return false, "", fmt.Errorf("unknown validation rule %q", rule)
+	}
+	if _, ok := ValidationMessages[rule]; !ok {
+		return fn(v, param), "invalid", nil
+	}
+	return fn(v, param), rule, nil
+}
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(pattern, re)
+	return re, nil
+}
+
+// splitRules splits the tag into rules and their parameters. The regexp rule takes the rest of the tag.
+func splitRules(tag string) [][2]string {
+	rules := [][2]string{}
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "regexp=") {
+			rule, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			rule, tag = tag[:i], tag[i+1:]
+		} else {
+			rule, tag = tag, ""
+		}
+		name, param := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, param = rule[:i], rule[i+1:]
+		}
+		rules = append(rules, [2]string{strings.TrimSpace(name), param})
+	}
+	return rules
+}
+
+func joinRules(rules [][2]string) string {
+	parts := make([]string, len(rules))
+	for i, rule := range rules {
+		parts[i] = rule[0]
+		if rule[1] != "" {
+			parts[i] += "=" + rule[1]
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func (errs *ValidationErrors) add(field, rule, param, key string) {
+	*errs = append(*errs, &FieldError{Field: field, Rule: rule, Param: param, key: key})
+}
+
+// translate sets the messages of the errors, translated by the localer if it is not nil.
+func (errs ValidationErrors) translate(l Localer) {
+	for _, e := range errs {
+		format := ValidationMessages[e.key]
+		if l != nil {
+			if s := l.Tr("validation." + e.key); s != e.key && s != "validation."+e.key && s != "" {
+				format = s
+			}
+		}
+		e.Message = strings.NewReplacer("{field}", e.Field, "{param}", e.Param).Replace(format)
+	}
+}
+
+// fieldName returns the name of the field in the json, form or query tags, or the field name.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "query"} {
+		if name := strings.Split(field.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func joinFieldName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// indirectValue dereferences pointers and interfaces, and returns an invalid value for nil ones.
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isZeroValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Invalid:
+		return true
+	}
+	return v.IsZero()
+}
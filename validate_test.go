@@ -0,0 +1,171 @@
+// Package makross is a high productive and modular web framework in Golang.
+
+package makross
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	validateAddress struct {
+		City string `json:"city" validate:"required"`
+		Zip  string `json:"zip" validate:"omitempty,len=5,regexp=^[0-9,]+$"`
+	}
+
+	validateUser struct {
+		Name     string             `json:"name" validate:"required,min=2,max=10"`
+		Email    string             `json:"email" validate:"required,email"`
+		Age      int                `json:"age" validate:"min=18,max=130"`
+		Role     string             `json:"role" validate:"oneof=admin user"`
+		Tags     []string           `json:"tags" validate:"max=3,dive,min=2"`
+		Address  *validateAddress   `json:"address" validate:"required"`
+		Previous []validateAddress  `json:"previous"`
+		Scores   map[string]float64 `json:"scores" validate:"dive,max=100"`
+		Nickname *string            `json:"nickname" validate:"min=3"`
+		Ignored  string             `validate:"-"`
+		internal string             `validate:"required"`
+	}
+)
+
+func validUser() *validateUser {
+	return &validateUser{
+		Name:    "John",
+		Email:   "john@example.com",
+		Age:     30,
+		Role:    "admin",
+		Tags:    []string{"go", "web"},
+		Address: &validateAddress{City: "Paris", Zip: "75001"},
+		Scores:  map[string]float64{"go": 99.5},
+	}
+}
+
+func fieldErrors(err error) map[string]string {
+	m := map[string]string{}
+	if errs, ok := err.(ValidationErrors); ok {
+		for _, e := range errs {
+			m[e.Field] = e.Message
+		}
+	}
+	return m
+}
+
+func TestDefaultValidator(t *testing.T) {
+	v := &DefaultValidator{}
+	assert.Nil(t, v.Validate(validUser()))
+	assert.Nil(t, v.Validate("not a struct"))
+	assert.Nil(t, v.Validate(nil))
+
+	u := validUser()
+	u.Name = "J"
+	u.Email = "john"
+	u.Age = 12
+	u.Role = "guest"
+	u.Tags = []string{"go", "x"}
+	u.Address.City = ""
+	u.Address.Zip = "7500"
+	u.Previous = []validateAddress{{City: "Lyon"}, {}}
+	u.Scores["web"] = 101
+	nickname := "jo"
+	u.Nickname = &nickname
+	assert.Equal(t, map[string]string{
+		"name":             "name must have a length of at least 2",
+		"email":            "email must be a valid email address",
+		"age":              "age must be at least 18",
+		"role":             "role must be one of [admin user]",
+		"tags[1]":          "tags[1] must have a length of at least 2",
+		"address.city":     "address.city is required",
+		"address.zip":      "address.zip must have a length of 5",
+		"previous[1].city": "previous[1].city is required",
+		"scores[web]":      "scores[web] must be at most 100",
+		"nickname":         "nickname must have a length of at least 3",
+	}, fieldErrors(v.Validate(u)))
+
+	u = validUser()
+	u.Name = ""
+	u.Email = ""
+	u.Tags = []string{"aa", "bb", "cc", "dd"}
+	u.Address = nil
+	err := v.Validate(u)
+	assert.Equal(t, map[string]string{
+		"name":    "name is required",
+		"email":   "email is required",
+		"tags":    "tags must have a length of at most 3",
+		"address": "address is required",
+	}, fieldErrors(err))
+	assert.Equal(t, "name is required; email is required; tags must have a length of at most 3; address is required", err.Error())
+
+	u = validUser()
+	u.Address.Zip = "7500a"
+	assert.Equal(t, map[string]string{"address.zip": "address.zip has an invalid format"}, fieldErrors(v.Validate(u)))
+
+	assert.NotNil(t, v.Validate(&struct {
+		A string `validate:"unknown"`
+	}{}))
+	assert.NotNil(t, v.Validate(&struct {
+		A string `validate:"min=x"`
+	}{}))
+	_, ok := v.Validate(&struct {
+		A bool `validate:"min=1"`
+	}{}).(ValidationErrors)
+	assert.False(t, ok)
+}
+
+func TestRegisterValidation(t *testing.T) {
+	RegisterValidation("prefix", func(v reflect.Value, param string) bool {
+		return strings.HasPrefix(v.String(), param)
+	})
+	defer delete(validations, "prefix")
+
+	data := &struct {
+		SKU string `validate:"prefix=SKU-"`
+	}{"ABC"}
+	err := (&DefaultValidator{}).Validate(data)
+	assert.Equal(t, map[string]string{"SKU": "SKU is invalid"}, fieldErrors(err))
+	data.SKU = "SKU-1"
+	assert.Nil(t, (&DefaultValidator{}).Validate(data))
+}
+
+type testLocaler struct{}
+
+func (l testLocaler) Language() string {
+	return "fr"
+}
+
+func (l testLocaler) Tr(key string, args ...interface{}) string {
+	if key == "validation.required" {
+		return "{field} est obligatoire"
+	}
+	return strings.TrimPrefix(key, "validation.")
+}
+
+func TestContextBindValidation(t *testing.T) {
+	m := New()
+	req := httptest.NewRequest(POST, "/", strings.NewReader(`{"name":"J","email":"","age":30,"role":"user","address":{"city":"Paris"}}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c := m.NewContext(req, httptest.NewRecorder())
+	c.Localer = testLocaler{}
+	err := c.Bind(&validateUser{})
+	if assert.IsType(t, new(HTTPError), err) {
+		he := err.(*HTTPError)
+		assert.Equal(t, http.StatusUnprocessableEntity, he.Status)
+		assert.Equal(t, "name must have a length of at least 2; email est obligatoire", he.Message)
+		b, _ := json.Marshal(he.Problem())
+		assert.Equal(t, `{"detail":"name must have a length of at least 2; email est obligatoire",`+
+			`"errors":[{"field":"name","rule":"min","param":"2","message":"name must have a length of at least 2"},`+
+			`{"field":"email","rule":"required","message":"email est obligatoire"}],`+
+			`"status":422,"title":"Unprocessable Entity","type":"about:blank"}`, string(b))
+	}
+
+	req = httptest.NewRequest(POST, "/", strings.NewReader(`{"name":"J"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	m.SetValidator(nil)
+	c = m.NewContext(req, httptest.NewRecorder())
+	assert.Nil(t, c.Bind(&validateUser{}))
+}
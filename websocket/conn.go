@@ -0,0 +1,381 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/insionng/makross"
+)
+
+// Message types defined in RFC 6455.
+const (
+	continuationFrame = 0
+	TextMessage       = 1
+	BinaryMessage     = 2
+	CloseMessage      = 8
+	PingMessage       = 9
+	PongMessage       = 10
+)
+
+// Close codes defined in RFC 6455.
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseInternalServerErr       = 1011
+)
+
+var (
+	// ErrClosed is returned when writing to a connection which has been closed.
+	ErrClosed = errors.New("websocket: connection closed")
+	// ErrReadLimit is returned when a message read from the peer exceeds the read limit.
+	ErrReadLimit = errors.New("websocket: message exceeds the read limit")
+	// ErrProtocol is returned when the peer violates the WebSocket protocol.
+	ErrProtocol = errors.New("websocket: protocol error")
+	// ErrInvalidUTF8 is returned when a text message read from the peer is not valid UTF-8.
+	ErrInvalidUTF8 = errors.New("websocket: invalid UTF-8 in text message")
+)
+
+// CloseError is returned by ReadMessage when the peer closes the connection.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+// Error returns the error message.
+func (e *CloseError) Error() string {
+	s := "websocket: closed with code " + strconv.Itoa(e.Code)
+	if e.Text != "" {
+		s += ": " + e.Text
+	}
+	return s
+}
+
+// Conn represents a WebSocket connection. Messages can be written by multiple goroutines
+// concurrently, while they should be read by a single goroutine.
+type Conn struct {
+	ctx          *makross.Context
+	conn         net.Conn
+	br           *bufio.Reader
+	subprotocol  string
+	readLimit    int64
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+
+	wmu       sync.Mutex // serializes the writes to conn
+	closeSent bool
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newConn(ctx *makross.Context, nc net.Conn, br *bufio.Reader, subprotocol string, config WebSocketConfig) *Conn {
+	c := &Conn{
+		ctx:          ctx,
+		conn:         nc,
+		br:           br,
+		subprotocol:  subprotocol,
+		readLimit:    config.ReadLimit,
+		writeTimeout: config.WriteTimeout,
+		done:         make(chan struct{}),
+	}
+	if config.PingInterval > 0 {
+		c.readTimeout = config.PingInterval + config.PongTimeout
+		go c.keepAlive(config.PingInterval)
+	}
+	return c
+}
+
+// Context returns the context of the upgrade request. It is only valid until the
+// handler that upgraded the connection returns.
+func (c *Conn) Context() *makross.Context {
+	return c.ctx
+}
+
+// Subprotocol returns the subprotocol negotiated during the handshake.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr returns the network address of the peer.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Done returns a channel that is closed when the connection is closed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// ReadMessage reads the next text or binary message from the peer.
+// Ping frames are answered automatically. When the peer closes the connection,
+// the close frame is answered and a *CloseError is returned.
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	for {
+		if c.readTimeout > 0 {
+			c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+		}
+		fin, opcode, payload, err := c.readFrame(c.readLimit - int64(len(data)))
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err := c.writeFrame(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			code, text := CloseNoStatusReceived, ""
+			if len(payload) == 1 {
+				return 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+			}
+			if len(payload) >= 2 {
+				code, text = int(binary.BigEndian.Uint16(payload)), string(payload[2:])
+				if !validCloseCode(code) || !utf8.ValidString(text) {
+					return 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+				}
+			}
+			c.closeWith(code, "")
+			return 0, nil, &CloseError{Code: code, Text: text}
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				// a new message starts before the fragmented one is finished
+				return 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+			}
+			messageType = opcode
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+		}
+
+		data = append(data, payload...)
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(data) {
+				return 0, nil, c.fail(CloseInvalidFramePayloadData, ErrInvalidUTF8)
+			}
+			return messageType, data, nil
+		}
+	}
+}
+
+// ReadJSON reads the next message from the peer and decodes it as JSON into v.
+func (c *Conn) ReadJSON(v interface{}) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// WriteMessage writes a text or binary message to the peer.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return errors.New("websocket: invalid message type " + strconv.Itoa(messageType))
+	}
+	return c.writeFrame(messageType, data)
+}
+
+// WriteText writes a text message to the peer.
+func (c *Conn) WriteText(s string) error {
+	return c.writeFrame(TextMessage, []byte(s))
+}
+
+// WriteJSON writes v as a JSON text message to the peer.
+func (c *Conn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(TextMessage, data)
+}
+
+// Ping sends a ping frame with the given application data to the peer.
+func (c *Conn) Ping(data []byte) error {
+	if len(data) > 125 {
+		return errors.New("websocket: control frame payload too long")
+	}
+	return c.writeFrame(PingMessage, data)
+}
+
+// Close closes the connection normally.
+func (c *Conn) Close() error {
+	return c.closeWith(CloseNormalClosure, "")
+}
+
+// CloseWithReason sends a close frame with the given code and reason to the peer and closes the connection.
+func (c *Conn) CloseWithReason(code int, reason string) error {
+	return c.closeWith(code, reason)
+}
+
+func (c *Conn) closeWith(code int, reason string) error {
+	var payload []byte
+	if code != CloseNoStatusReceived {
+		if len(reason) > 123 {
+			reason = reason[:123]
+		}
+		payload = make([]byte, 2, 2+len(reason))
+		binary.BigEndian.PutUint16(payload, uint16(code))
+		payload = append(payload, reason...)
+	}
+
+	c.wmu.Lock()
+	var err error
+	if !c.closeSent {
+		c.closeSent = true
+		err = c.write(encodeFrame(CloseMessage, payload))
+	}
+	c.wmu.Unlock()
+
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if cerr := c.conn.Close(); err == nil {
+			err = cerr
+		}
+	})
+	return err
+}
+
+// fail closes the connection because the peer has sent invalid data and returns err.
+func (c *Conn) fail(code int, err error) error {
+	c.closeWith(code, "")
+	return err
+}
+
+// validCloseCode returns whether the status code may be sent in a close frame (RFC 6455, section 7.4).
+// The codes below 1000, the reserved ones, such as 1005, 1006 and 1015, and the unassigned ones are invalid.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		// registered by IANA, or for private use
+		return true
+	}
+	return false
+}
+
+// readFrame reads a frame from the peer. The payload of a data frame may not exceed limit.
+func (c *Conn) readFrame(limit int64) (fin bool, opcode int, payload []byte, err error) {
+	var h [14]byte
+	if _, err = io.ReadFull(c.br, h[:2]); err != nil {
+		return
+	}
+	fin = h[0]&0x80 != 0
+	opcode = int(h[0] & 0x0f)
+	if h[0]&0x70 != 0 || h[1]&0x80 == 0 {
+		// no extension is negotiated, and the frames sent by a client must be masked
+		return false, 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+	}
+
+	n := int64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		if _, err = io.ReadFull(c.br, h[2:4]); err != nil {
+			return
+		}
+		n = int64(binary.BigEndian.Uint16(h[2:4]))
+	case 127:
+		if _, err = io.ReadFull(c.br, h[2:10]); err != nil {
+			return
+		}
+		n = int64(binary.BigEndian.Uint64(h[2:10]))
+		if n < 0 {
+			return false, 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+		}
+	}
+	if opcode >= CloseMessage {
+		if !fin || n > 125 {
+			return false, 0, nil, c.fail(CloseProtocolError, ErrProtocol)
+		}
+	} else if n > limit {
+		return false, 0, nil, c.fail(CloseMessageTooBig, ErrReadLimit)
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i&3]
+	}
+	return
+}
+
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	return c.writeEncoded(encodeFrame(opcode, payload))
+}
+
+// writeEncoded writes a frame encoded by encodeFrame, which allows a broadcast message to be encoded once.
+func (c *Conn) writeEncoded(frame []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return ErrClosed
+	}
+	return c.write(frame)
+}
+
+// write writes an encoded frame. The caller must hold wmu.
+func (c *Conn) write(frame []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// encodeFrame encodes an unfragmented and unmasked frame, as sent by a server.
+func encodeFrame(opcode int, payload []byte) []byte {
+	n := len(payload)
+	frame := make([]byte, 0, n+10)
+	frame = append(frame, 0x80|byte(opcode))
+	switch {
+	case n <= 125:
+		frame = append(frame, byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 126, byte(n>>8), byte(n))
+	default:
+		frame = append(frame, 127)
+		frame = frame[:10]
+		binary.BigEndian.PutUint64(frame[2:], uint64(n))
+	}
+	return append(frame, payload...)
+}
+
+// keepAlive sends pings at the given interval until the connection is closed.
+func (c *Conn) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.writeFrame(PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package websocket
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnReadErrors(t *testing.T) {
+	server := echoServer(WebSocketConfig{ReadLimit: 10})
+	defer server.Close()
+
+	tests := []struct {
+		id   string
+		send func(c *testClient)
+		code int
+	}{
+		{"too big", func(c *testClient) { c.send(TextMessage, true, []byte("01234567890")) }, CloseMessageTooBig},
+		{"too big fragmented", func(c *testClient) {
+			c.send(TextMessage, false, []byte("012345"))
+			c.send(continuationFrame, true, []byte("012345"))
+		}, CloseMessageTooBig},
+		{"invalid utf8", func(c *testClient) { c.send(TextMessage, true, []byte{0xff, 0xfe}) }, CloseInvalidFramePayloadData},
+		{"unexpected continuation", func(c *testClient) { c.send(continuationFrame, true, []byte("a")) }, CloseProtocolError},
+		{"unknown opcode", func(c *testClient) { c.send(3, true, []byte("a")) }, CloseProtocolError},
+		{"fragmented control", func(c *testClient) { c.send(PingMessage, false, nil) }, CloseProtocolError},
+		{"unmasked", func(c *testClient) { c.conn.Write([]byte{0x81, 0x01, 'a'}) }, CloseProtocolError},
+		{"close code below 1000", func(c *testClient) { c.send(CloseMessage, true, []byte{0x03, 0xe7}) }, CloseProtocolError},
+		{"reserved close code", func(c *testClient) { c.send(CloseMessage, true, []byte{0x03, 0xed}) }, CloseProtocolError},
+		{"invalid utf8 close reason", func(c *testClient) { c.send(CloseMessage, true, []byte{0x03, 0xe8, 0xff}) }, CloseProtocolError},
+		{"private close code", func(c *testClient) { c.send(CloseMessage, true, []byte{0x0f, 0xa0, 'b', 'y', 'e'}) }, 4000},
+	}
+	for _, test := range tests {
+		client, res := dial(t, server, "/ws", nil)
+		assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode, test.id)
+		test.send(client)
+		opcode, payload := client.read()
+		assert.Equal(t, CloseMessage, opcode, test.id)
+		assert.Equal(t, test.code, closeCode(payload), test.id)
+		client.conn.Close()
+	}
+}
+
+func TestConnPing(t *testing.T) {
+	server := echoServer(WebSocketConfig{PingInterval: 10 * time.Millisecond})
+	defer server.Close()
+
+	client, _ := dial(t, server, "/ws", nil)
+	defer client.conn.Close()
+	opcode, _ := client.read()
+	assert.Equal(t, PingMessage, opcode)
+
+	// the connection is closed when the client does not respond
+	client.conn.SetReadDeadline(time.Now().Add(time.Second))
+	for opcode == PingMessage {
+		opcode, _ = client.read()
+	}
+	assert.Equal(t, CloseMessage, opcode)
+}
+
+func TestValidCloseCode(t *testing.T) {
+	for _, code := range []int{CloseNormalClosure, CloseGoingAway, CloseInvalidFramePayloadData, CloseInternalServerErr, 3000, 4999} {
+		assert.True(t, validCloseCode(code), "%d", code)
+	}
+	for _, code := range []int{0, 999, 1004, CloseNoStatusReceived, CloseAbnormalClosure, 1015, 2000, 2999, 5000} {
+		assert.False(t, validCloseCode(code), "%d", code)
+	}
+}
+
+func TestEncodeFrame(t *testing.T) {
+	assert.Equal(t, []byte{0x81, 2, 'h', 'i'}, encodeFrame(TextMessage, []byte("hi")))
+	assert.Equal(t, []byte{0x82, 126, 0x01, 0x00}, encodeFrame(BinaryMessage, make([]byte, 256))[:4])
+	assert.Equal(t, []byte{0x82, 127, 0, 0, 0, 0, 0, 1, 0, 0}, encodeFrame(BinaryMessage, make([]byte, 65536))[:10])
+	assert.Equal(t, "websocket: closed with code 1001: bye", (&CloseError{CloseGoingAway, "bye"}).Error())
+}
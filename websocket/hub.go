@@ -0,0 +1,142 @@
+package websocket
+
+import (
+	"sort"
+	"sync"
+)
+
+// Hub keeps track of connections joined to rooms and broadcasts messages to them.
+// It is safe for concurrent use.
+type Hub struct {
+	mu    sync.RWMutex
+	rooms map[string]map[*Conn]bool
+}
+
+// NewHub creates a new Hub.
+func NewHub() *Hub {
+	return &Hub{rooms: make(map[string]map[*Conn]bool)}
+}
+
+// Join adds the connection to the room.
+func (h *Hub) Join(room string, c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	members := h.rooms[room]
+	if members == nil {
+		members = make(map[*Conn]bool)
+		h.rooms[room] = members
+	}
+	members[c] = true
+}
+
+// Leave removes the connection from the room.
+func (h *Hub) Leave(room string, c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(room, c)
+}
+
+// Remove removes the connection from all rooms. It should be called when the connection is closed.
+func (h *Hub) Remove(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for room := range h.rooms {
+		h.leave(room, c)
+	}
+}
+
+func (h *Hub) leave(room string, c *Conn) {
+	if members := h.rooms[room]; members != nil {
+		delete(members, c)
+		if len(members) == 0 {
+			delete(h.rooms, room)
+		}
+	}
+}
+
+// Rooms returns the names of the rooms having at least one connection, in alphabetical order.
+func (h *Hub) Rooms() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	rooms := make([]string, 0, len(h.rooms))
+	for room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	sort.Strings(rooms)
+	return rooms
+}
+
+// Members returns the connections joined to the room.
+func (h *Hub) Members(room string) []*Conn {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	conns := make([]*Conn, 0, len(h.rooms[room]))
+	for c := range h.rooms[room] {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// Broadcast sends a text or binary message to all connections joined to the room except the given ones,
+// and returns the number of connections the message was sent to. The message is sent to the connections
+// concurrently; connections that fail to receive it are closed and removed from the hub.
+func (h *Hub) Broadcast(room string, messageType int, data []byte, except ...*Conn) int {
+	return h.broadcast(h.Members(room), messageType, data, except)
+}
+
+// BroadcastAll sends a text or binary message to the connections joined to any room except the given ones.
+// See Broadcast for more details.
+func (h *Hub) BroadcastAll(messageType int, data []byte, except ...*Conn) int {
+	h.mu.RLock()
+	seen := make(map[*Conn]bool)
+	conns := []*Conn{}
+	for _, members := range h.rooms {
+		for c := range members {
+			if !seen[c] {
+				seen[c] = true
+				conns = append(conns, c)
+			}
+		}
+	}
+	h.mu.RUnlock()
+	return h.broadcast(conns, messageType, data, except)
+}
+
+func (h *Hub) broadcast(conns []*Conn, messageType int, data []byte, except []*Conn) int {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return 0
+	}
+	frame := encodeFrame(messageType, data)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sent := 0
+	for _, c := range conns {
+		if containsConn(except, c) {
+			continue
+		}
+		wg.Add(1)
+		go func(c *Conn) {
+			defer wg.Done()
+			if err := c.writeEncoded(frame); err != nil {
+				h.Remove(c)
+				c.Close()
+				return
+			}
+			mu.Lock()
+			sent++
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+	return sent
+}
+
+func containsConn(conns []*Conn, c *Conn) bool {
+	for _, conn := range conns {
+		if conn == c {
+			return true
+		}
+	}
+	return false
+}
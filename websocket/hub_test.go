@@ -0,0 +1,66 @@
+package websocket
+
+import (
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/insionng/makross"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHub(t *testing.T) {
+	hub := NewHub()
+	joined := make(chan *Conn, 3)
+	var wg sync.WaitGroup
+
+	m := makross.New()
+	m.Get("/rooms/<room>", WebSocket(func(conn *Conn) {
+		defer wg.Done()
+		room := conn.Context().Param("room").String()
+		hub.Join(room, conn)
+		defer hub.Remove(conn)
+		joined <- conn
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			hub.Broadcast(room, TextMessage, data, conn)
+		}
+	}))
+	server := httptest.NewServer(m)
+	defer server.Close()
+
+	wg.Add(3)
+	a, _ := dial(t, server, "/rooms/go", nil)
+	b, _ := dial(t, server, "/rooms/go", nil)
+	c, _ := dial(t, server, "/rooms/rust", nil)
+	conns := []*Conn{<-joined, <-joined, <-joined}
+	assert.Equal(t, []string{"go", "rust"}, hub.Rooms())
+	assert.Len(t, hub.Members("go"), 2)
+
+	// the message is broadcast to the room except the sender
+	a.send(TextMessage, true, []byte("hello gophers"))
+	opcode, payload := b.read()
+	assert.Equal(t, TextMessage, opcode)
+	assert.Equal(t, "hello gophers", string(payload))
+
+	assert.Equal(t, 3, hub.BroadcastAll(TextMessage, []byte("to all")))
+	for _, client := range []*testClient{a, b, c} {
+		_, payload = client.read()
+		assert.Equal(t, "to all", string(payload))
+	}
+	assert.Equal(t, 2, hub.BroadcastAll(TextMessage, []byte("x"), conns[0]))
+	assert.Equal(t, 0, hub.Broadcast("go", PingMessage, nil))
+
+	hub.Leave("rust", conns[2])
+	assert.Equal(t, []string{"go"}, hub.Rooms())
+
+	for _, client := range []*testClient{a, b, c} {
+		client.send(CloseMessage, true, []byte{0x03, 0xe8})
+		client.conn.Close()
+	}
+	wg.Wait()
+	assert.Empty(t, hub.Rooms())
+}
@@ -0,0 +1,207 @@
+// Package websocket implements the WebSocket protocol (RFC 6455) for the makross.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/insionng/makross"
+)
+
+// guid is appended to the key sent by the client to compute the Sec-WebSocket-Accept header.
+const guid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+type (
+	// WebSocketConfig defines the config for WebSocket upgrades.
+	WebSocketConfig struct {
+		// CheckOrigin returns whether the upgrade request is allowed for its Origin header.
+		// Optional. By default, requests without the Origin header and requests whose origin
+		// has the same host as the request are allowed.
+		CheckOrigin func(c *makross.Context) bool
+
+		// Subprotocols lists the supported subprotocols in the order of preference.
+		// Optional. Default value []string{}.
+		Subprotocols []string
+
+		// ReadLimit is the maximum size in bytes of a message read from the peer.
+		// Optional. Default value 32 MB.
+		ReadLimit int64
+
+		// PingInterval is the interval of the pings sent to the peer to keep the connection alive.
+		// Optional. Default value 0, which disables the pings.
+		PingInterval time.Duration
+
+		// PongTimeout is how long to wait for a frame from the peer after a ping is sent
+		// before the connection is considered broken.
+		// Optional. Default value PingInterval.
+		PongTimeout time.Duration
+
+		// WriteTimeout is the time limit for writing a message to the peer.
+		// Optional. Default value 10 seconds.
+		WriteTimeout time.Duration
+	}
+)
+
+var (
+	// DefaultWebSocketConfig is the default WebSocket config.
+	DefaultWebSocketConfig = WebSocketConfig{
+		CheckOrigin:  checkSameOrigin,
+		ReadLimit:    32 << 20,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	// ErrHijackUnsupported is returned by Upgrade when the response writer does not support hijacking.
+	ErrHijackUnsupported = errors.New("websocket: response does not support hijacking")
+)
+
+// WebSocket returns a handler that upgrades the requests to WebSocket connections and calls fn with them.
+// The handlers registered before it (such as authentication and request ID) are run on the upgrade request,
+// and the headers they set are sent with the handshake response. The connection is closed after fn returns.
+func WebSocket(fn func(*Conn)) makross.Handler {
+	return WebSocketWithConfig(DefaultWebSocketConfig, fn)
+}
+
+// WebSocketWithConfig returns a WebSocket handler with config.
+// See WebSocket() for more details.
+func WebSocketWithConfig(config WebSocketConfig, fn func(*Conn)) makross.Handler {
+	return func(c *makross.Context) error {
+		conn, err := UpgradeWithConfig(c, config)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		fn(conn)
+		return nil
+	}
+}
+
+// Upgrade upgrades the request of the context to a WebSocket connection with the default config.
+func Upgrade(c *makross.Context) (*Conn, error) {
+	return UpgradeWithConfig(c, DefaultWebSocketConfig)
+}
+
+// UpgradeWithConfig upgrades the request of the context to a WebSocket connection.
+// An *makross.HTTPError is returned if the request is not a valid WebSocket handshake.
+// The connection takes over the response, which must not be written by the handlers any more.
+func UpgradeWithConfig(c *makross.Context, config WebSocketConfig) (*Conn, error) {
+	// Defaults
+	if config.CheckOrigin == nil {
+		config.CheckOrigin = DefaultWebSocketConfig.CheckOrigin
+	}
+	if config.ReadLimit == 0 {
+		config.ReadLimit = DefaultWebSocketConfig.ReadLimit
+	}
+	if config.PongTimeout == 0 {
+		config.PongTimeout = config.PingInterval
+	}
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = DefaultWebSocketConfig.WriteTimeout
+	}
+
+	req := c.Request
+	if req.Method != makross.GET {
+		return nil, makross.NewHTTPError(makross.StatusMethodNotAllowed)
+	}
+	if !headerContains(req.Header, "Connection", "upgrade") || !headerContains(req.Header, makross.HeaderUpgrade, "websocket") {
+		return nil, makross.NewHTTPError(makross.StatusBadRequest, "not a websocket handshake")
+	}
+	if req.Header.Get("Sec-WebSocket-Version") != "13" {
+		c.Response.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, makross.NewHTTPError(http.StatusUpgradeRequired, "unsupported websocket version")
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		return nil, makross.NewHTTPError(makross.StatusBadRequest, "invalid websocket key")
+	}
+	if !config.CheckOrigin(c) {
+		return nil, makross.NewHTTPError(makross.StatusForbidden, "origin not allowed")
+	}
+	if _, ok := c.Response.Writer.(http.Hijacker); !ok {
+		return nil, ErrHijackUnsupported
+	}
+
+	subprotocol := selectSubprotocol(req, config.Subprotocols)
+	header := c.Response.Header()
+	header.Del(makross.HeaderContentType)
+	header.Set(makross.HeaderUpgrade, "websocket")
+	header.Set("Connection", "Upgrade")
+	header.Set("Sec-WebSocket-Accept", acceptKey(key))
+	if subprotocol != "" {
+		header.Set("Sec-WebSocket-Protocol", subprotocol)
+	}
+
+	// the handshake is written on the hijacked connection, after the functions
+	// that must run before the response header is written, such as the session saving
+	c.Response.Status = makross.StatusSwitchingProtocols
+	c.Response.RunBefore()
+	nc, brw, err := c.Response.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	c.Response.Committed = true
+
+	nc.SetDeadline(time.Time{})
+	nc.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+	w := bufio.NewWriter(nc)
+	w.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	header.Write(w)
+	w.WriteString("\r\n")
+	if err := w.Flush(); err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	conn := newConn(c, nc, brw.Reader, subprotocol, config)
+	return conn, nil
+}
+
+// acceptKey computes the value of the Sec-WebSocket-Accept header for the given key.
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + guid))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// selectSubprotocol returns the first of the supported subprotocols, in their order of preference,
+// that is requested by the client.
+func selectSubprotocol(req *http.Request, supported []string) string {
+	requested := strings.Split(req.Header.Get("Sec-WebSocket-Protocol"), ",")
+	for _, s := range supported {
+		for _, r := range requested {
+			if strings.TrimSpace(r) == s {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// headerContains returns whether the comma-separated header contains the token, ignoring case.
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func checkSameOrigin(c *makross.Context) bool {
+	origin := c.Request.Header.Get(makross.HeaderOrigin)
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, c.Request.Host)
+}
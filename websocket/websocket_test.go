@@ -0,0 +1,189 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/insionng/makross"
+	"github.com/stretchr/testify/assert"
+)
+
+// testClient is a minimal WebSocket client used to test the server side.
+type testClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dial(t *testing.T, server *httptest.Server, path string, header http.Header) (*testClient, *http.Response) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", server.URL+path, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testClient{conn: conn, br: br}, res
+}
+
+// send sends a masked frame.
+func (c *testClient) send(opcode int, fin bool, payload []byte) {
+	b0 := byte(opcode)
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126, byte(n>>8), byte(n))
+	default:
+		frame = append(frame, 0x80|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(n))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	c.conn.Write(frame)
+}
+
+// read reads an unmasked frame.
+func (c *testClient) read() (opcode int, payload []byte) {
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		return -1, nil
+	}
+	n := int(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		io.ReadFull(c.br, b[:])
+		n = int(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		io.ReadFull(c.br, b[:])
+		n = int(binary.BigEndian.Uint64(b[:]))
+	}
+	payload = make([]byte, n)
+	io.ReadFull(c.br, payload)
+	return int(h[0] & 0x0f), payload
+}
+
+func closeCode(payload []byte) int {
+	if len(payload) < 2 {
+		return CloseNoStatusReceived
+	}
+	return int(binary.BigEndian.Uint16(payload))
+}
+
+func echoServer(config WebSocketConfig) *httptest.Server {
+	m := makross.New()
+	m.Use(func(c *makross.Context) error {
+		c.Response.Header().Set(makross.HeaderXRequestID, "abc")
+		c.Set("user", "john")
+		c.Response.Before(func() {
+			c.Response.Header().Set("X-Before", strconv.Itoa(c.Response.Status))
+		})
+		return c.Next()
+	})
+	m.Get("/ws", WebSocketWithConfig(config, func(conn *Conn) {
+		for {
+			t, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(data) == "who" {
+				data = []byte(conn.Context().Get("user").(string))
+			}
+			conn.WriteMessage(t, data)
+		}
+	}))
+	return httptest.NewServer(m)
+}
+
+func TestWebSocketHandshake(t *testing.T) {
+	server := echoServer(WebSocketConfig{Subprotocols: []string{"chat", "superchat"}})
+	defer server.Close()
+
+	client, res := dial(t, server, "/ws", http.Header{"Sec-Websocket-Protocol": {"superchat, chat"}})
+	defer client.conn.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", res.Header.Get("Sec-WebSocket-Accept"))
+	assert.Equal(t, "chat", res.Header.Get("Sec-WebSocket-Protocol"), "the server preference wins")
+	assert.Equal(t, "abc", res.Header.Get(makross.HeaderXRequestID))
+	assert.Equal(t, "101", res.Header.Get("X-Before"))
+
+	client.send(TextMessage, true, []byte("hello"))
+	opcode, payload := client.read()
+	assert.Equal(t, TextMessage, opcode)
+	assert.Equal(t, "hello", string(payload))
+
+	client.send(TextMessage, true, []byte("who"))
+	_, payload = client.read()
+	assert.Equal(t, "john", string(payload))
+
+	// a fragmented message with a ping in between
+	client.send(BinaryMessage, false, []byte("frag"))
+	client.send(PingMessage, true, []byte("p"))
+	client.send(continuationFrame, true, []byte(strings.Repeat("x", 300)))
+	opcode, payload = client.read()
+	assert.Equal(t, PongMessage, opcode)
+	assert.Equal(t, "p", string(payload))
+	opcode, payload = client.read()
+	assert.Equal(t, BinaryMessage, opcode)
+	assert.Equal(t, "frag"+strings.Repeat("x", 300), string(payload))
+
+	client.send(CloseMessage, true, []byte{0x03, 0xe8})
+	opcode, payload = client.read()
+	assert.Equal(t, CloseMessage, opcode)
+	assert.Equal(t, CloseNormalClosure, closeCode(payload))
+}
+
+func TestWebSocketRejected(t *testing.T) {
+	server := echoServer(DefaultWebSocketConfig)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/ws")
+	if assert.Nil(t, err) {
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	}
+
+	client, res := dial(t, server, "/ws", http.Header{"Origin": {"http://evil.example.com"}})
+	client.conn.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	client, res = dial(t, server, "/ws", http.Header{"Sec-Websocket-Version": {"8"}})
+	client.conn.Close()
+	assert.Equal(t, http.StatusUpgradeRequired, res.StatusCode)
+	assert.Equal(t, "13", res.Header.Get("Sec-WebSocket-Version"))
+
+	client, res = dial(t, server, "/ws", http.Header{"Origin": {server.URL}})
+	client.conn.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+}
+
+func TestAcceptKey(t *testing.T) {
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", acceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}